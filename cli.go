@@ -0,0 +1,116 @@
+package twist
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Parsed command-line option in argument order
+type cliArg struct {
+	// Option name without dashes
+	name string
+
+	// Option as written like "--host", "-h"
+	flag string
+
+	// Index in the arguments
+	position int
+}
+
+// UnknownOptionError represents a command-line option which does not correspond to any cli tag
+type UnknownOptionError struct {
+	// Option as written like "--hots"
+	Option string
+
+	// Index of the option in the arguments
+	Position int
+
+	// Most similar known option like "--host", empty if there is no similar one
+	Suggestion string
+}
+
+func (e *UnknownOptionError) Error() string {
+	message := fmt.Sprintf("unknown flag %s", e.Option)
+	if e.Suggestion != "" {
+		message += fmt.Sprintf(", did you mean %s?", e.Suggestion)
+	}
+	return message
+}
+
+// UnknownOptionErrors aggregates all unknown options in argument order
+type UnknownOptionErrors []*UnknownOptionError
+
+func (e UnknownOptionErrors) Error() string {
+	messages := make([]string, len(e))
+	for i := range e {
+		messages[i] = e[i].Error()
+	}
+	return strings.Join(messages, ", ")
+}
+
+// Unwrap returns each unknown option so that errors.As() can find *UnknownOptionError
+func (e UnknownOptionErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i := range e {
+		errs[i] = e[i]
+	}
+	return errs
+}
+
+// Build errors of unrecognized options in argument order with suggestions.
+// Short name is suggested for single character option, otherwise long name is suggested.
+func unknownOptionErrors(ctx *Context, t reflect.Type, parsed []cliArg, unrecognized map[string][]string) error {
+	if len(unrecognized) == 0 {
+		return nil
+	}
+	var short, long []string
+	for _, name := range planOf(t).cliNames {
+		if len(name) == 1 {
+			short = append(short, name)
+		} else {
+			long = append(long, name)
+		}
+	}
+	for name := range ctx.configFlags {
+		if len(name) == 1 {
+			short = append(short, name)
+		} else {
+			long = append(long, name)
+		}
+	}
+	sort.Strings(short)
+	sort.Strings(long)
+
+	var errs UnknownOptionErrors
+	reported := make(map[string]struct{})
+	for _, arg := range parsed {
+		if _, ok := unrecognized[arg.name]; !ok {
+			continue
+		}
+		if _, ok := reported[arg.name]; ok {
+			continue
+		}
+		reported[arg.name] = struct{}{}
+
+		candidates := long
+		if len(arg.name) == 1 {
+			candidates = short
+		}
+		found := suggest(arg.name, candidates)
+		// Long name might be written with single dash like "-host"
+		if found == "" && len(arg.name) > 1 {
+			found = suggest(arg.name, append(append([]string{}, long...), short...))
+		}
+		e := &UnknownOptionError{
+			Option:   arg.flag,
+			Position: arg.position,
+		}
+		if found != "" {
+			e.Suggestion = cliFlagName(found)
+		}
+		errs = append(errs, e)
+	}
+	return errs
+}
@@ -0,0 +1,98 @@
+package twist
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Will cascade from the config file which path is specified by the command-line option or environment variable,
+// like `myapp --config /etc/myapp.yaml` or `MYAPP_CONFIG=/etc/myapp.yaml myapp`.
+// names is comma separated option names as same as cli tag (e.g. "c,config") and env is the environment variable name,
+// either can be empty. Command-line option takes precedence over the environment variable.
+//
+// The file is cascaded at the position of this option by WithFile(), so that the following options like WithEnv() and WithCli()
+// can override it. Command-line arguments are taken from WithCli() option, or os.Args if WithCli() is not specified,
+// and the consumed option is not treated as unrecognized option.
+// Nothing is cascaded if neither is specified.
+func WithConfigFileFlag(names, env string) Option {
+	s := &configFileSource{env: env}
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			s.names = append(s.names, name)
+		}
+	}
+	return Option{
+		source: s,
+		apply: func(ctx *Context) {
+			if ctx.configFlags == nil {
+				ctx.configFlags = make(map[string]struct{})
+			}
+			for _, name := range s.names {
+				ctx.configFlags[name] = struct{}{}
+			}
+		},
+	}
+}
+
+// Source which cascades the config file specified by command-line option or environment variable
+type configFileSource struct {
+	names []string
+	env   string
+
+	// Resolved path on the last cascading, used for watching
+	mu   sync.Mutex
+	path string
+}
+
+func (s *configFileSource) Name() string {
+	return "file"
+}
+
+// Find config file path and the location where it is specified
+func (s *configFileSource) lookup(ctx *Context, t reflect.Type) (string, string) {
+	args := ctx.args
+	if args == nil {
+		args = os.Args[1:]
+	}
+	options, _ := parseCliArgs(reflect.New(derefType(t)).Elem(), args)
+	for _, name := range s.names {
+		if values, ok := options[name]; ok && values[len(values)-1] != "" {
+			return values[len(values)-1], cliFlagName(name)
+		}
+	}
+	if s.env != "" {
+		if v := os.Getenv(s.env); v != "" {
+			return v, s.env
+		}
+	}
+	return "", ""
+}
+
+func (s *configFileSource) files(ctx *Context) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.path == "" {
+		return nil
+	}
+	return []string{s.path}
+}
+
+func (s *configFileSource) Cascade(ctx *Context, v reflect.Value) error {
+	path, location := s.lookup(ctx, v.Type())
+	s.mu.Lock()
+	s.path = path
+	s.mu.Unlock()
+
+	if path == "" {
+		ctx.logEvent(actionNotFound, "", strings.Join(s.names, ","), "env", s.env)
+		return nil
+	}
+	ctx.Logger().Debug("twist: config file", "path", path, "from", location)
+	if err := WithFile(path).source.Cascade(ctx, v); err != nil {
+		return fmt.Errorf("Failed to cascade config file specified by %s: %w", location, err)
+	}
+	return nil
+}
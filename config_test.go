@@ -0,0 +1,63 @@
+package twist_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	twist "github.com/ysugimoto/twist"
+)
+
+func TestWithConfigFileFlag(t *testing.T) {
+	t.Setenv("TWIST_CONFIG", "./fixtures/example.yaml")
+
+	t.Run("from cli", func(t *testing.T) {
+		config := fixtureConfig{}
+		report, err := twist.MixWithReport(
+			&config,
+			twist.WithConfigFileFlag("c,config", "TWIST_CONFIG"),
+			twist.WithCli([]string{"-v", "--config", "./fixtures/example.toml", "--host", "cli.localhost"}),
+		)
+		assert.NoError(t, err)
+		assert.True(t, config.Verbose)
+		assert.Equal(t, "token_from_toml", config.Token)
+		assert.Equal(t, "cli.localhost", config.Server.Host)
+		assert.Equal(t, 9999, config.Server.Port)
+
+		p, ok := report.Lookup("Token")
+		assert.True(t, ok)
+		assert.Equal(t, "toml", p.Source)
+		assert.Equal(t, "./fixtures/example.toml", p.Location)
+	})
+
+	t.Run("from env", func(t *testing.T) {
+		config := fixtureConfig{}
+		err := twist.Mix(
+			&config,
+			twist.WithConfigFileFlag("c,config", "TWIST_CONFIG"),
+			twist.WithCli([]string{"-v"}),
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, "token_from_yaml", config.Token)
+	})
+
+	t.Run("not specified", func(t *testing.T) {
+		config := fixtureConfig{}
+		err := twist.Mix(
+			&config,
+			twist.WithConfigFileFlag("c,config", ""),
+			twist.WithCli([]string{}),
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, "", config.Token)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		config := fixtureConfig{}
+		err := twist.Mix(
+			&config,
+			twist.WithConfigFileFlag("c,config", ""),
+			twist.WithCli([]string{"-c", "./fixtures/not_found.toml"}),
+		)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,117 @@
+package twist
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+)
+
+// Will cascade all files which have supported extension (toml, yaml, yml, json, ini, env and registered ones) in the directory.
+// Files are cascaded in lexical order and the format is detected by the extension,
+// so that drop-in fragments like conf.d/10-base.toml, conf.d/20-local.yaml can override in order.
+// Sub directories and files which have unsupported extension are ignored.
+func WithDir(dir string) Option {
+	return Option{
+		source: &dirSource{dir: dir},
+	}
+}
+
+// Will cascade all files which match the pattern like "/etc/app/conf.d/*.toml" in lexical order.
+// The pattern syntax is the same as filepath.Match, and unsupported file extension is an error.
+func WithGlob(pattern string) Option {
+	return Option{
+		source: &dirSource{pattern: pattern},
+	}
+}
+
+// Source which cascades files in the directory or matched with the glob pattern
+type dirSource struct {
+	dir     string
+	pattern string
+}
+
+func (s *dirSource) Name() string {
+	if s.dir != "" {
+		return "dir"
+	}
+	return "glob"
+}
+
+// Returns the directory and matched files in order to watch adding and removing files
+func (s *dirSource) files(ctx *Context) []string {
+	matches, _ := s.matches(ctx)
+	if s.dir != "" {
+		return append([]string{s.dir}, matches...)
+	}
+	return matches
+}
+
+// Collect matched files in lexical order
+func (s *dirSource) matches(ctx *Context) ([]string, error) {
+	var matches []string
+	if s.dir != "" {
+		var entries []fs.DirEntry
+		var err error
+		if ctx.fs == nil {
+			entries, err = os.ReadDir(s.dir)
+		} else {
+			entries, err = fs.ReadDir(ctx.fs, fsPath(s.dir))
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			if _, ok := lookupExtension(entry.Name()); !ok {
+				continue
+			}
+			matches = append(matches, filepath.Join(s.dir, entry.Name()))
+		}
+	} else {
+		var err error
+		if ctx.fs == nil {
+			matches, err = filepath.Glob(s.pattern)
+		} else {
+			matches, err = fs.Glob(ctx.fs, fsPath(s.pattern))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+func (s *dirSource) Cascade(ctx *Context, v reflect.Value) error {
+	matches, err := s.matches(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to collect files: %w", err)
+	}
+
+	name := ctx.source
+	defer func() {
+		ctx.source = name
+	}()
+	for _, file := range matches {
+		if info, err := ctx.statFile(file); err != nil {
+			return fmt.Errorf("file stat error: %w", err)
+		} else if info.IsDir() {
+			continue
+		}
+		opt, ok := optionByExtension(file)
+		if !ok {
+			return fmt.Errorf("Unsupported file extension: %s", file)
+		}
+		// Record each value with the source kind of the file format
+		ctx.source = opt.source.Name()
+		if err := opt.source.Cascade(ctx, v); err != nil {
+			return fmt.Errorf("Failed to cascade %s: %w", file, err)
+		}
+	}
+	return nil
+}
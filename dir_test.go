@@ -0,0 +1,50 @@
+package twist_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	twist "github.com/ysugimoto/twist"
+)
+
+func TestWithDir(t *testing.T) {
+	config := fixtureConfig{}
+	report, err := twist.MixWithReport(&config, twist.WithDir("./fixtures/conf.d"))
+	assert.NoError(t, err)
+	assert.Equal(t, "token_from_dotenv", config.Token)
+	assert.Equal(t, "conf.d.localhost", config.Server.Host)
+	assert.Equal(t, 8080, config.Server.Port)
+
+	p, ok := report.Lookup("Server.Port")
+	assert.True(t, ok)
+	assert.Equal(t, "yaml", p.Source)
+	assert.Equal(t, "fixtures/conf.d/20-port.yaml", p.Location)
+
+	err = twist.Mix(&config, twist.WithDir("./fixtures/not_found"))
+	assert.Error(t, err)
+	err = twist.Mix(&config, twist.Optional(twist.WithDir("./fixtures/not_found")))
+	assert.NoError(t, err)
+}
+
+func TestWithGlob(t *testing.T) {
+	config := fixtureConfig{}
+	err := twist.Mix(&config, twist.WithGlob("./fixtures/conf.d/*.toml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "token_from_toml", config.Token)
+	assert.Equal(t, 8000, config.Server.Port)
+
+	err = twist.Mix(&config, twist.WithGlob("./fixtures/conf.d/*.txt"))
+	assert.Error(t, err)
+}
+
+func TestWithDirFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"conf.d/b.yaml": {Data: []byte("server:\n  port: 9090\n")},
+		"conf.d/a.toml": {Data: []byte("[server]\nport = 8080\n")},
+	}
+	config := fixtureConfig{}
+	err := twist.Mix(&config, twist.WithFS(fsys), twist.WithDir("conf.d"))
+	assert.NoError(t, err)
+	assert.Equal(t, 9090, config.Server.Port)
+}
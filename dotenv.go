@@ -0,0 +1,173 @@
+package twist
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Parse dotenv format document.
+// Supported syntax:
+//   - comment line starts with "#", and inline comment after unquoted value
+//   - "export" prefix
+//   - single quoted value which is literal
+//   - double quoted value which supports escape sequences (\n, \r, \t, \", \\, \$)
+//   - multi-line value in quotes
+//   - ${VAR}, $VAR and ${VAR:-default} expansion in unquoted and double quoted value,
+//     VAR is looked up from the variables defined above, then environment variables
+func parseDotenv(src string) (map[string]string, error) {
+	values := make(map[string]string)
+	lookup := func(name string) (string, bool) {
+		if v, ok := values[name]; ok {
+			return v, true
+		}
+		return os.LookupEnv(name)
+	}
+
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNumber := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "export ") || strings.HasPrefix(line, "export\t") {
+			line = strings.TrimSpace(line[len("export"):])
+		}
+
+		idx := strings.Index(line, "=")
+		if idx == -1 {
+			return nil, fmt.Errorf("line %d: missing \"=\" separator", lineNumber)
+		}
+		key := strings.TrimSpace(line[:idx])
+		if !isValidEnvName(key) {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", lineNumber, key)
+		}
+		raw := strings.TrimLeft(line[idx+1:], " \t")
+
+		var value string
+		switch {
+		case strings.HasPrefix(raw, "'"), strings.HasPrefix(raw, `"`):
+			quote := raw[0]
+			body := raw[1:]
+			// Read following lines until closing quote is found
+			end := findClosingQuote(body, quote)
+			for end == -1 && i+1 < len(lines) {
+				i++
+				body += "\n" + lines[i]
+				end = findClosingQuote(body, quote)
+			}
+			if end == -1 {
+				return nil, fmt.Errorf("line %d: unterminated quoted value", lineNumber)
+			}
+			rest := strings.TrimSpace(body[end+1:])
+			if rest != "" && !strings.HasPrefix(rest, "#") {
+				return nil, fmt.Errorf("line %d: unexpected characters after quoted value", lineNumber)
+			}
+			value = body[:end]
+			if quote == '"' {
+				value = expandEnv(value, lookup, true)
+			}
+		default:
+			if idx := strings.Index(raw, " #"); idx != -1 {
+				raw = raw[:idx]
+			} else if idx := strings.Index(raw, "\t#"); idx != -1 {
+				raw = raw[:idx]
+			}
+			value = expandEnv(strings.TrimSpace(raw), lookup, false)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// Check the name is valid as environment variable name
+func isValidEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Find index of closing quote, escaped quote is skipped in double quoted value
+func findClosingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		if quote == '"' && s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == quote {
+			return i
+		}
+	}
+	return -1
+}
+
+// Expand ${VAR}, $VAR and ${VAR:-default} in the value, "\$" is treated as literal "$".
+// Escape sequences of double quoted value are unescaped in the same pass if unescape is true,
+// so that escaped backslash like "\\$HOME" is not confused with escaped "$".
+func expandEnv(s string, lookup func(string) (string, bool), unescape bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == '$':
+			b.WriteByte('$')
+			i++
+		case s[i] == '\\' && i+1 < len(s) && unescape:
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\':
+				b.WriteByte(s[i])
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(s[i])
+			}
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			end := strings.Index(s[i:], "}")
+			if end == -1 {
+				b.WriteString(s[i:])
+				return b.String()
+			}
+			name := s[i+2 : i+end]
+			var fallback string
+			if idx := strings.Index(name, ":-"); idx != -1 {
+				name, fallback = name[:idx], name[idx+2:]
+			}
+			if v, ok := lookup(name); ok && v != "" {
+				b.WriteString(v)
+			} else {
+				b.WriteString(fallback)
+			}
+			i += end
+		case s[i] == '$':
+			j := i + 1
+			for j < len(s) && (s[j] == '_' || s[j] >= 'A' && s[j] <= 'Z' || s[j] >= 'a' && s[j] <= 'z' || j > i+1 && s[j] >= '0' && s[j] <= '9') {
+				j++
+			}
+			if j == i+1 {
+				b.WriteByte('$')
+				continue
+			}
+			v, _ := lookup(s[i+1 : j])
+			b.WriteString(v)
+			i = j - 1
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
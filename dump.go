@@ -0,0 +1,457 @@
+package twist
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-ini/ini"
+	"github.com/go-yaml/yaml"
+)
+
+// Format is the output format of Marshal and Dump
+type Format string
+
+// Supported dump formats
+const (
+	FormatToml   Format = "toml"
+	FormatYaml   Format = "yaml"
+	FormatJson   Format = "json"
+	FormatIni    Format = "ini"
+	FormatDotenv Format = "dotenv"
+	FormatCli    Format = "cli"
+)
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// Marshal encodes the configuration struct in the format using the format's own tags,
+// so that the output can be read back by corresponding WithXXX option and produces the same struct.
+// Fields which don't have the format's tag are omitted, and secret fields are masked.
+// opts are used for the settings like WithEnvPrefix() and WithAutoEnv() on dotenv format.
+func Marshal(v interface{}, format Format, opts ...Option) ([]byte, error) {
+	value := derefValue(reflect.ValueOf(v))
+	if !value.IsValid() || value.Kind() != reflect.Struct {
+		return nil, errors.New("Marshaling value must be a struct")
+	}
+	ctx := &Context{}
+	for _, opt := range opts {
+		if opt.apply != nil {
+			opt.apply(ctx)
+		}
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case FormatToml:
+		if err := toml.NewEncoder(&buf).Encode(dumpTree(value, tagNameToml).toMap()); err != nil {
+			return nil, fmt.Errorf("toml encode error: %w", err)
+		}
+	case FormatYaml:
+		out, err := yaml.Marshal(dumpTree(value, tagNameYaml))
+		if err != nil {
+			return nil, fmt.Errorf("yaml encode error: %w", err)
+		}
+		buf.Write(out)
+	case FormatJson:
+		out, err := json.MarshalIndent(dumpTree(value, tagNameJson), "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("json encode error: %w", err)
+		}
+		buf.Write(out)
+		buf.WriteString("\n")
+	case FormatIni:
+		cfg := ini.Empty()
+		if err := dumpIni(cfg, cfg.Section(""), value, planOf(value.Type())); err != nil {
+			return nil, fmt.Errorf("ini encode error: %w", err)
+		}
+		if _, err := cfg.WriteTo(&buf); err != nil {
+			return nil, fmt.Errorf("ini encode error: %w", err)
+		}
+	case FormatDotenv:
+		for _, line := range dumpDotenv(ctx, value, "", nil) {
+			buf.WriteString(line + "\n")
+		}
+	case FormatCli:
+		args := dumpCli(value, nil)
+		for i := range args {
+			args[i] = shellQuote(args[i])
+		}
+		buf.WriteString(strings.Join(args, " ") + "\n")
+	default:
+		return nil, fmt.Errorf("Unsupported format: %s", format)
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalArgs encodes the configuration struct as command-line arguments using cli tags,
+// which can be passed to WithCli() directly. Secret fields are masked as same as Marshal().
+func MarshalArgs(v interface{}) ([]string, error) {
+	value := derefValue(reflect.ValueOf(v))
+	if !value.IsValid() || value.Kind() != reflect.Struct {
+		return nil, errors.New("Marshaling value must be a struct")
+	}
+	return dumpCli(value, nil), nil
+}
+
+// Dump writes marshaled configuration to w
+func Dump(w io.Writer, v interface{}, format Format, opts ...Option) error {
+	out, err := Marshal(v, format, opts...)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(out); err != nil {
+		return fmt.Errorf("Failed to write dump: %w", err)
+	}
+	return nil
+}
+
+// Ordered key-value pairs of the dumped struct which keeps struct field order
+type dumpMap []dumpItem
+
+type dumpItem struct {
+	key   string
+	value interface{}
+}
+
+func (m dumpMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i, item := range m {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		key, err := json.Marshal(item.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(item.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteString(":")
+		buf.Write(value)
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+func (m dumpMap) MarshalYAML() (interface{}, error) {
+	ms := make(yaml.MapSlice, len(m))
+	for i, item := range m {
+		ms[i] = yaml.MapItem{Key: item.key, Value: item.value}
+	}
+	return ms, nil
+}
+
+// Convert to map for the encoder which does not care about order
+func (m dumpMap) toMap() map[string]interface{} {
+	ret := make(map[string]interface{}, len(m))
+	for _, item := range m {
+		switch value := item.value.(type) {
+		case dumpMap:
+			ret[item.key] = value.toMap()
+		case []dumpMap:
+			tables := make([]map[string]interface{}, len(value))
+			for i := range value {
+				tables[i] = value[i].toMap()
+			}
+			ret[item.key] = tables
+		default:
+			ret[item.key] = item.value
+		}
+	}
+	return ret
+}
+
+// Walk struct field and build ordered tree keyed by the tag name.
+// Leaf values are kept as is in order to be encoded natively by each format package.
+func dumpTree(v reflect.Value, tagName string) dumpMap {
+	t := v.Type()
+	tree := dumpMap{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !isEmbeddedStruct(field) {
+			continue
+		}
+		value := derefValue(v.Field(i))
+		if !value.IsValid() {
+			continue
+		}
+
+		tag, ok := field.Tag.Lookup(tagName)
+		if isInlineField(field, tagName) {
+			// Embedded struct is inlined
+			tree = append(tree, dumpTree(value, tagName)...)
+			continue
+		}
+		if !ok || tag == "" || tag == "-" {
+			continue
+		}
+		item := dumpItem{key: tagKeyName(tag)}
+		if isSecret(field) {
+			item.value = secretMask
+		} else if isNestedStruct(value.Type()) {
+			item.value = dumpTree(value, tagName)
+		} else if elems, ok := dumpElements(value, tagName); ok {
+			item.value = elems
+		} else {
+			item.value = value.Interface()
+		}
+		tree = append(tree, item)
+	}
+	return tree
+}
+
+// Build trees of slice elements or map entries which are nested struct in order to mask secret fields in them.
+// Slice is built as a list of trees, and map is built as a tree which is sorted by key.
+func dumpElements(v reflect.Value, tagName string) (interface{}, bool) {
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Map || !isNestedStruct(derefType(v.Type().Elem())) {
+		return nil, false
+	}
+	if v.Kind() == reflect.Slice {
+		list := make([]dumpMap, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			// Nil element is dumped as empty tree because toml cannot encode null
+			elem := dumpMap{}
+			if value := derefValue(v.Index(i)); value.IsValid() {
+				elem = dumpTree(value, tagName)
+			}
+			list = append(list, elem)
+		}
+		return list, true
+	}
+	tree := dumpMap{}
+	for _, key := range v.MapKeys() {
+		if value := derefValue(v.MapIndex(key)); value.IsValid() {
+			tree = append(tree, dumpItem{key: fmt.Sprint(key.Interface()), value: dumpTree(value, tagName)})
+		}
+	}
+	sort.Slice(tree, func(i, j int) bool {
+		return tree[i].key < tree[j].key
+	})
+	return tree, true
+}
+
+// Walk struct field and write to ini section.
+// Nested struct is written as the section which is named by dotted path of ini tags like [server.tls],
+// and usage tag is written as the comment of the key.
+func dumpIni(cfg *ini.File, s *ini.Section, v reflect.Value, plan *typePlan) error {
+	for _, f := range plan.fields {
+		if !f.exported || f.cyclic {
+			continue
+		}
+		value := derefValue(v.Field(f.index))
+		if !value.IsValid() {
+			continue
+		}
+
+		tag, ok := f.keys[tagNameIni]
+		if !ok {
+			continue
+		}
+		if f.nested != nil {
+			keyPath, ok := f.keyPaths[tagNameIni]
+			if !ok {
+				continue
+			}
+			ss, err := cfg.NewSection(strings.Join(keyPath, "."))
+			if err != nil {
+				return err
+			}
+			if err := dumpIni(cfg, ss, value, f.nested); err != nil {
+				return err
+			}
+			continue
+		}
+		key, err := s.NewKey(tag, maskValue(f.field, formatString(value, f.field.Tag)))
+		if err != nil {
+			return err
+		}
+		if usage := f.field.Tag.Get(tagNameUsage); usage != "" {
+			key.Comment = "; " + usage
+		}
+	}
+	return nil
+}
+
+// Walk struct field and build dotenv lines with the same naming as WithEnv()
+func dumpDotenv(ctx *Context, v reflect.Value, prefix string, lines []string) []string {
+	for _, f := range planOf(v.Type()).fields {
+		if !f.exported {
+			continue
+		}
+		value := derefValue(v.Field(f.index))
+		if !value.IsValid() {
+			continue
+		}
+
+		if isNestedStruct(value.Type()) {
+			lines = dumpDotenv(ctx, value, ctx.envNestedPrefix(prefix, f), lines)
+			continue
+		}
+		name, ok := ctx.envName(prefix, f)
+		if !ok {
+			continue
+		}
+		lines = append(lines, name+"="+dotenvQuote(maskValue(f.field, formatString(value, f.field.Tag))))
+	}
+	return lines
+}
+
+// Walk struct field and build command-line arguments.
+// Long option name is preferred, and zero values are omitted.
+func dumpCli(v reflect.Value, args []string) []string {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !isEmbeddedStruct(field) {
+			continue
+		}
+		value := derefValue(v.Field(i))
+		if !value.IsValid() {
+			continue
+		}
+
+		if isNestedStruct(value.Type()) {
+			args = dumpCli(value, args)
+			continue
+		}
+		tag, ok := field.Tag.Lookup(tagNameCli)
+		if !ok || tag == "" || tag == "-" || value.IsZero() {
+			continue
+		}
+		names := strings.Split(tag, ",")
+		name := strings.TrimSpace(names[0])
+		for _, n := range names {
+			if n = strings.TrimSpace(n); len(n) > 1 {
+				name = n
+				break
+			}
+		}
+		// Long option takes value as "--name=value", short option takes as "-n value"
+		option := func(value string) []string {
+			if len(name) > 1 {
+				return []string{cliFlagName(name) + "=" + value}
+			}
+			return []string{cliFlagName(name), value}
+		}
+
+		switch {
+		case isSecret(field):
+			args = append(args, option(secretMask)...)
+		case value.Kind() == reflect.Bool:
+			args = append(args, cliFlagName(name))
+		case isCollectionType(value.Type()):
+			for _, elem := range formatElements(value, field.Tag) {
+				args = append(args, option(elem)...)
+			}
+		default:
+			args = append(args, option(formatString(value, field.Tag))...)
+		}
+	}
+	return args
+}
+
+// Format value as string which can be parsed by assignValue
+func formatString(v reflect.Value, tag reflect.StructTag) string {
+	v = derefValue(v)
+	if !v.IsValid() {
+		return ""
+	}
+
+	switch v.Type() {
+	case durationType:
+		return time.Duration(v.Int()).String()
+	case timeType:
+		layout := tag.Get(tagNameLayout)
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		return v.Interface().(time.Time).Format(layout)
+	case urlType:
+		u := v.Interface().(url.URL)
+		return u.String()
+	}
+
+	if v.Type().Implements(textMarshalerType) {
+		if text, err := v.Interface().(encoding.TextMarshaler).MarshalText(); err == nil {
+			return string(text)
+		}
+	} else if v.CanAddr() && v.Addr().Type().Implements(textMarshalerType) {
+		if text, err := v.Addr().Interface().(encoding.TextMarshaler).MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+	if isCollectionType(v.Type()) {
+		sep := defaultSep
+		if s, ok := tag.Lookup(tagNameSep); ok && s != "" {
+			sep = s
+		}
+		return strings.Join(formatElements(v, tag), sep)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// Format slice elements or map entries as strings, map entries are sorted by key
+func formatElements(v reflect.Value, tag reflect.StructTag) []string {
+	var elems []string
+	switch v.Kind() {
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			elems = append(elems, formatString(v.Index(i), tag))
+		}
+	case reflect.Map:
+		kvsep := defaultKvSep
+		if s, ok := tag.Lookup(tagNameKvSep); ok && s != "" {
+			kvsep = s
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			elems = append(elems, formatString(iter.Key(), tag)+kvsep+formatString(iter.Value(), tag))
+		}
+		sort.Strings(elems)
+	}
+	return elems
+}
+
+// Quote dotenv value with double quotes if it contains special characters
+func dotenvQuote(s string) string {
+	if s != "" && !strings.ContainsFunc(s, func(r rune) bool {
+		return !isSafeChar(r)
+	}) {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// Quote command-line argument with single quotes if it contains special characters
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsFunc(s, func(r rune) bool {
+		return !isSafeChar(r) && r != '='
+	}) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Check the character does not need quoting
+func isSafeChar(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	}
+	return strings.ContainsRune("-_.,:/@+%", r)
+}
@@ -0,0 +1,101 @@
+package twist_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	twist "github.com/ysugimoto/twist"
+)
+
+type dumpServer struct {
+	Host string `toml:"host" yaml:"host" json:"host" ini:"host" env:"HOST" cli:"host"`
+	Port int    `toml:"port" yaml:"port" json:"port" ini:"port" env:"PORT" cli:"p,port"`
+}
+
+type dumpConfig struct {
+	Token   string        `toml:"token" yaml:"token" json:"token" ini:"token" env:"TOKEN" cli:"token"`
+	Debug   bool          `toml:"debug" yaml:"debug" json:"debug" ini:"debug" env:"DEBUG" cli:"debug"`
+	Timeout time.Duration `toml:"timeout" yaml:"timeout" json:"timeout" ini:"timeout" env:"TIMEOUT" cli:"timeout"`
+	Hosts   []string      `toml:"hosts" yaml:"hosts" json:"hosts" ini:"hosts" env:"HOSTS" cli:"hosts"`
+	Server  dumpServer    `toml:"server" yaml:"server" json:"server" ini:"server"`
+}
+
+func newDumpConfig() dumpConfig {
+	return dumpConfig{
+		Token:   "my secret \"token\"",
+		Debug:   true,
+		Timeout: 30 * time.Second,
+		Hosts:   []string{"a.localhost", "b.localhost"},
+		Server: dumpServer{
+			Host: "localhost",
+			Port: 8080,
+		},
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	tests := []struct {
+		format twist.Format
+		option func(string) twist.Option
+	}{
+		{format: twist.FormatToml, option: twist.WithToml},
+		{format: twist.FormatYaml, option: twist.WithYaml},
+		{format: twist.FormatJson, option: twist.WithJson},
+		{format: twist.FormatIni, option: twist.WithIni},
+	}
+
+	expect := newDumpConfig()
+	for _, tt := range tests {
+		out, err := twist.Marshal(&expect, tt.format)
+		assert.NoError(t, err)
+
+		file := filepath.Join(t.TempDir(), "config."+string(tt.format))
+		assert.NoError(t, os.WriteFile(file, out, 0o644))
+
+		var actual dumpConfig
+		assert.NoError(t, twist.Mix(&actual, tt.option(file)), string(tt.format))
+		assert.Equal(t, expect, actual, string(tt.format))
+	}
+}
+
+func TestMarshalDotenv(t *testing.T) {
+	expect := newDumpConfig()
+	out, err := twist.Marshal(&expect, twist.FormatDotenv, twist.WithEnvPrefix("DUMP"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), `DUMP_TOKEN="my secret \"token\""`)
+	assert.Contains(t, string(out), "DUMP_HOSTS=a.localhost,b.localhost\n")
+
+	file := filepath.Join(t.TempDir(), ".env")
+	assert.NoError(t, os.WriteFile(file, out, 0o644))
+
+	var actual dumpConfig
+	assert.NoError(t, twist.Mix(&actual, twist.WithDotenv(file), twist.WithEnvPrefix("DUMP")))
+	assert.Equal(t, expect, actual)
+}
+
+func TestMarshalArgs(t *testing.T) {
+	expect := newDumpConfig()
+	args, err := twist.MarshalArgs(&expect)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`--token=my secret "token"`,
+		"--debug",
+		"--timeout=30s",
+		"--hosts=a.localhost",
+		"--hosts=b.localhost",
+		"--host=localhost",
+		"--port=8080",
+	}, args)
+
+	var actual dumpConfig
+	assert.NoError(t, twist.Mix(&actual, twist.WithCli(args)))
+	assert.Equal(t, expect, actual)
+
+	var buf bytes.Buffer
+	assert.NoError(t, twist.Dump(&buf, &expect, twist.FormatCli))
+	assert.Equal(t, `'--token=my secret "token"' --debug --timeout=30s --hosts=a.localhost --hosts=b.localhost --host=localhost --port=8080`+"\n", buf.String())
+}
@@ -0,0 +1,55 @@
+package twist
+
+import (
+	"strings"
+	"unicode"
+)
+
+const tagNameEnvPrefix = "envPrefix"
+
+// Resolve environment variable name of the field.
+// Name comes from env tag, or is derived from field name on auto naming mode,
+// then prefixed with nested struct segments and the global prefix.
+func (c *Context) envName(prefix string, f *fieldPlan) (string, bool) {
+	tag := f.env
+	if tag == "-" {
+		return "", false
+	}
+	if !f.hasEnv || tag == "" {
+		if !c.autoEnv {
+			return "", false
+		}
+		tag = f.autoEnvName
+	}
+	return c.envPrefix + prefix + tag, true
+}
+
+// Resolve environment variable name segment for the nested struct field.
+// envPrefix tag is used if exists, otherwise the field name is used on auto naming mode.
+// Embedded struct does not add any segment.
+func (c *Context) envNestedPrefix(prefix string, f *fieldPlan) string {
+	if f.hasPrefix {
+		return prefix + f.envPrefix
+	}
+	if c.autoEnv && !f.field.Anonymous {
+		return prefix + f.autoEnvName + "_"
+	}
+	return prefix
+}
+
+// Convert Go field name to upper snake case like "ServerPort" -> "SERVER_PORT", "APIKey" -> "API_KEY"
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
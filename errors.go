@@ -0,0 +1,74 @@
+package twist
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// FieldError represents a failure of assigning the value to the field
+type FieldError struct {
+	// Source kind like "env", "cli", "default"
+	Source string
+
+	// File path, environment variable name or cli flag where the value comes from, empty for default tag
+	Location string
+
+	// Dotted field path like "Server.Port"
+	Path string
+
+	// Raw string value, masked if the field is secret
+	Value string
+
+	// Type of the destination field
+	Type reflect.Type
+
+	// Underlying conversion error
+	Err error
+}
+
+func (e *FieldError) Error() string {
+	from := e.Source
+	if e.Location != "" {
+		from += " " + e.Location
+	}
+	return fmt.Sprintf("failed to assign %q to %s (%s) from %s: %v", e.Value, e.Path, e.Type, from, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// SourceError represents a failure of reading or decoding the source
+type SourceError struct {
+	// Source kind like "toml", "yaml"
+	Source string
+
+	// File path, or "(reader)" for reader and bytes sources
+	Location string
+
+	// Underlying error
+	Err error
+}
+
+func (e *SourceError) Error() string {
+	if e.Location == "" {
+		return fmt.Sprintf("%s: %v", e.Source, e.Err)
+	}
+	return fmt.Sprintf("%s %s: %v", e.Source, e.Location, e.Err)
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// Create FieldError of the current source. Secret value is masked in both the value and the error.
+func (c *Context) fieldError(location, path string, field reflect.StructField, value string, err error) error {
+	return &FieldError{
+		Source:   c.source,
+		Location: location,
+		Path:     path,
+		Value:    maskValue(field, value),
+		Type:     field.Type,
+		Err:      maskError(field, err, value),
+	}
+}
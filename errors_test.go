@@ -0,0 +1,76 @@
+package twist_test
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	twist "github.com/ysugimoto/twist"
+)
+
+func TestFieldError(t *testing.T) {
+	t.Setenv("TWIST_PORT", "abc")
+	config := struct {
+		Server struct {
+			Port int `env:"TWIST_PORT"`
+		}
+	}{}
+	err := twist.Mix(&config, twist.WithEnv())
+	assert.Error(t, err)
+
+	var fe *twist.FieldError
+	assert.True(t, errors.As(err, &fe))
+	assert.Equal(t, "env", fe.Source)
+	assert.Equal(t, "TWIST_PORT", fe.Location)
+	assert.Equal(t, "Server.Port", fe.Path)
+	assert.Equal(t, "abc", fe.Value)
+	assert.Equal(t, reflect.TypeOf(0), fe.Type)
+	assert.True(t, errors.Is(err, strconv.ErrSyntax))
+	assert.EqualError(t, err, `Failed to cascade env: failed to assign "abc" to Server.Port (int) from env TWIST_PORT: failed to convert from string to int: strconv.ParseInt: parsing "abc": invalid syntax`)
+}
+
+func TestFieldErrorSources(t *testing.T) {
+	config := struct {
+		Timeout time.Duration `cli:"t,timeout" ini:"timeout" default:"1x"`
+	}{}
+	tests := []struct {
+		name     string
+		option   twist.Option
+		source   string
+		location string
+	}{
+		{name: "cli", option: twist.WithCli([]string{"-t", "1x"}), source: "cli", location: "-t"},
+		{name: "ini", option: twist.WithIniBytes([]byte("timeout = 1x\n")), source: "ini", location: "(reader)"},
+		{name: "default", option: twist.WithEnv(), source: "default", location: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := twist.Mix(&config, tt.option)
+			var fe *twist.FieldError
+			assert.True(t, errors.As(err, &fe))
+			assert.Equal(t, tt.source, fe.Source)
+			assert.Equal(t, tt.location, fe.Location)
+			assert.Equal(t, "Timeout", fe.Path)
+			assert.Equal(t, "1x", fe.Value)
+		})
+	}
+}
+
+func TestSourceError(t *testing.T) {
+	config := struct {
+		Port int `toml:"port" json:"port"`
+	}{}
+	err := twist.Mix(&config, twist.WithToml("./fixtures/example.yaml"))
+	var se *twist.SourceError
+	assert.True(t, errors.As(err, &se))
+	assert.Equal(t, "toml", se.Source)
+	assert.Equal(t, "./fixtures/example.yaml", se.Location)
+
+	err = twist.Mix(&config, twist.WithJson("./fixtures/not_found.json"))
+	assert.True(t, errors.As(err, &se))
+	assert.Equal(t, "json", se.Source)
+	assert.Equal(t, "./fixtures/not_found.json", se.Location)
+}
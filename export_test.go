@@ -0,0 +1,9 @@
+package twist
+
+// ResetPlanCache clears compiled field plans so that benchmarks can measure cascading without the cache
+func ResetPlanCache() {
+	plans.Range(func(key, _ any) bool {
+		plans.Delete(key)
+		return true
+	})
+}
@@ -0,0 +1,179 @@
+package twist
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+)
+
+var (
+	extensionMu sync.RWMutex
+	// File options for each file extension
+	extensionOptions = map[string]func(path string) Option{
+		".toml": WithToml,
+		".yaml": WithYaml,
+		".yml":  WithYaml,
+		".json": WithJson,
+		".ini":  WithIni,
+		".env":  WithDotenv,
+	}
+)
+
+// RegisterExtension registers the file option for the file extension like ".hcl" or ".conf",
+// which is used by WithFile(), WithDir() and WithGlob() to detect the format.
+// with can be a builtin file option like WithIni, or a constructor of the custom source via WithSource().
+// Registering the same extension overrides the previous one.
+func RegisterExtension(ext string, with func(path string) Option) {
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	extensionMu.Lock()
+	defer extensionMu.Unlock()
+	extensionOptions[strings.ToLower(ext)] = with
+}
+
+// Find file option constructor by the file extension
+func lookupExtension(file string) (func(path string) Option, bool) {
+	extensionMu.RLock()
+	defer extensionMu.RUnlock()
+	with, ok := extensionOptions[strings.ToLower(filepath.Ext(file))]
+	return with, ok
+}
+
+// Find file option by the file extension
+func optionByExtension(file string) (Option, bool) {
+	with, ok := lookupExtension(file)
+	if !ok {
+		return Option{}, false
+	}
+	return with(file), true
+}
+
+// Will cascade from the config file which format is detected by the extension.
+// If the extension is unknown (e.g. "/etc/myapp/config"), the format is detected from the content,
+// so you can pass a path of any supported type like a value of --config option.
+func WithFile(path string) Option {
+	return Option{
+		source: &anyFileSource{fileContent{path: path}},
+	}
+}
+
+// File source which dispatches to the source of the detected format
+type anyFileSource struct {
+	fileContent
+}
+
+func (s *anyFileSource) Name() string {
+	return "file"
+}
+
+func (s *anyFileSource) Cascade(ctx *Context, v reflect.Value) error {
+	var source Source
+	if opt, ok := optionByExtension(s.path); ok {
+		source = opt.source
+	} else {
+		buf, err := s.read(ctx)
+		if err != nil {
+			return &SourceError{Source: s.Name(), Location: s.location(), Err: err}
+		}
+		if source = sniffSource(s.path, buf); source == nil {
+			return &SourceError{Source: s.Name(), Location: s.location(), Err: errors.New("failed to detect file format")}
+		}
+	}
+
+	// Record each value with the source kind of the file format
+	name := ctx.source
+	defer func() {
+		ctx.source = name
+	}()
+	ctx.source = source.Name()
+	return source.Cascade(ctx, v)
+}
+
+// Detect file format from the content
+func sniffFormat(buf []byte) string {
+	trimmed := bytes.TrimSpace(buf)
+	if len(trimmed) == 0 {
+		return ""
+	}
+	switch {
+	case trimmed[0] == '{':
+		return optionNameJson
+	case bytes.HasPrefix(trimmed, []byte("---")):
+		return optionNameYaml
+	}
+	// Dotenv like `TOKEN="abc"` is also valid toml, so it is checked before decoding as toml
+	if isDotenvContent(trimmed) {
+		return optionNameDotenv
+	}
+	var tmp map[string]interface{}
+	if _, err := toml.Decode(string(buf), &tmp); err == nil {
+		return optionNameToml
+	}
+
+	// Decide by the first significant line
+	for _, line := range strings.Split(string(trimmed), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' {
+			return optionNameIni
+		}
+		colon := strings.Index(line, ":")
+		equal := strings.Index(line, "=")
+		switch {
+		case colon > 0 && (equal < 0 || colon < equal):
+			return optionNameYaml
+		case equal > 0:
+			key := strings.TrimSpace(strings.TrimPrefix(line[:equal], "export "))
+			if isValidEnvName(key) && strings.ToUpper(key) == key {
+				return optionNameDotenv
+			}
+			return optionNameIni
+		}
+		return ""
+	}
+	return ""
+}
+
+// Check every significant line is upper case variable assignment like "[export ]NAME=value"
+func isDotenvContent(buf []byte) bool {
+	for _, line := range strings.Split(string(buf), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		equal := strings.Index(line, "=")
+		if equal <= 0 {
+			return false
+		}
+		key := strings.TrimSpace(strings.TrimPrefix(line[:equal], "export "))
+		if !isValidEnvName(key) || strings.ToUpper(key) != key {
+			return false
+		}
+	}
+	return true
+}
+
+// Detect file format from the content and returns the source which has the content
+func sniffSource(path string, buf []byte) Source {
+	switch sniffFormat(buf) {
+	case optionNameJson:
+		return &jsonSource{fileContent{path: path, data: buf}}
+	case optionNameYaml:
+		return &yamlSource{fileContent{path: path, data: buf}}
+	case optionNameToml:
+		return &tomlSource{fileContent{path: path, data: buf}}
+	case optionNameIni:
+		return &iniSource{fileContent{path: path, data: buf}}
+	case optionNameDotenv:
+		return &dotenvSource{fileContent{path: path, data: buf}}
+	}
+	return nil
+}
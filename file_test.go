@@ -0,0 +1,75 @@
+package twist_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	twist "github.com/ysugimoto/twist"
+)
+
+func TestWithFile(t *testing.T) {
+	config := fixtureConfig{}
+	report, err := twist.MixWithReport(
+		&config,
+		twist.WithFile("./fixtures/example.toml"),
+		twist.WithFile("./fixtures/example.override.yaml"),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "token_from_toml", config.Token)
+	assert.Equal(t, "toml.localhost", config.Server.Host)
+	assert.Equal(t, 8080, config.Server.Port)
+
+	p, ok := report.Lookup("Server.Port")
+	assert.True(t, ok)
+	assert.Equal(t, "yaml", p.Source)
+}
+
+func TestWithFileSniffing(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		source  string
+	}{
+		{name: "toml", content: "token = \"sniffed\"\n[server]\nport = 8080\n", source: "toml"},
+		{name: "yaml", content: "token: sniffed\nserver:\n  port: 8080\n", source: "yaml"},
+		{name: "json", content: `{"token": "sniffed", "server": {"port": 8080}}`, source: "json"},
+		{name: "ini", content: "token = sniffed\n[server]\nhost = localhost\nport = 8080\n", source: "ini"},
+		{name: "dotenv", content: "# comment\nexport TWIST_TOKEN=sniffed\n", source: "dotenv"},
+		{name: "dotenv quoted", content: "TWIST_TOKEN=\"sniffed\"\nPORT=8080\n", source: "dotenv"},
+	}
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(dir, tt.name)
+			assert.NoError(t, os.WriteFile(file, []byte(tt.content), 0o644))
+
+			config := fixtureConfig{}
+			report, err := twist.MixWithReport(&config, twist.WithFile(file))
+			assert.NoError(t, err)
+			assert.Equal(t, "sniffed", config.Token)
+			p, ok := report.Lookup("Token")
+			assert.True(t, ok)
+			assert.Equal(t, tt.source, p.Source)
+		})
+	}
+
+	file := filepath.Join(dir, "empty")
+	assert.NoError(t, os.WriteFile(file, nil, 0o644))
+	err := twist.Mix(&fixtureConfig{}, twist.WithFile(file))
+	assert.Error(t, err)
+}
+
+func TestRegisterExtension(t *testing.T) {
+	twist.RegisterExtension("twistconf", twist.WithIni)
+
+	file := filepath.Join(t.TempDir(), "app.twistconf")
+	assert.NoError(t, os.WriteFile(file, []byte("token = registered\n[server]\nhost = localhost\nport = 8080\n"), 0o644))
+
+	config := fixtureConfig{}
+	err := twist.Mix(&config, twist.WithFile(file))
+	assert.NoError(t, err)
+	assert.Equal(t, "registered", config.Token)
+	assert.Equal(t, 8080, config.Server.Port)
+}
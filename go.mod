@@ -6,7 +6,6 @@ require (
 	github.com/BurntSushi/toml v1.3.2
 	github.com/go-ini/ini v1.67.0
 	github.com/go-yaml/yaml v2.1.0+incompatible
-	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.3.0
 )
 
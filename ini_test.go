@@ -0,0 +1,125 @@
+package twist_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	twist "github.com/ysugimoto/twist"
+)
+
+type iniTLS struct {
+	Cert string `ini:"cert" usage:"Path to certificate file"`
+	Key  string `ini:"key"`
+}
+
+type iniConfig struct {
+	Token  string `ini:"token"`
+	Server struct {
+		Host    string   `ini:"host"`
+		Port    int      `ini:"port"`
+		Origins []string `ini:"origin"`
+		TLS     *iniTLS  `ini:"tls"`
+	} `ini:"server"`
+}
+
+func TestMixIniNestedSection(t *testing.T) {
+	src := strings.Join([]string{
+		"token = ini_token",
+		"[server]",
+		"host = ini.localhost",
+		"port = 8080",
+		"[server.tls]",
+		"cert = /etc/ssl/server.crt",
+		"key = /etc/ssl/server.key",
+	}, "\n")
+
+	var config iniConfig
+	err := twist.Mix(&config, twist.WithIniBytes([]byte(src)))
+	assert.NoError(t, err)
+	assert.Equal(t, "ini_token", config.Token)
+	assert.Equal(t, "ini.localhost", config.Server.Host)
+	assert.Equal(t, 8080, config.Server.Port)
+	if assert.NotNil(t, config.Server.TLS) {
+		assert.Equal(t, "/etc/ssl/server.crt", config.Server.TLS.Cert)
+		assert.Equal(t, "/etc/ssl/server.key", config.Server.TLS.Key)
+	}
+}
+
+func TestMixIniKeepNilPointer(t *testing.T) {
+	var config iniConfig
+	config.Server.Port = 3000
+	err := twist.Mix(&config, twist.WithIniBytes([]byte("[server]\nhost = ini.localhost\n")))
+	assert.NoError(t, err)
+	assert.Equal(t, "ini.localhost", config.Server.Host)
+	// Missing key must not overwrite the value
+	assert.Equal(t, 3000, config.Server.Port)
+	assert.Nil(t, config.Server.TLS)
+}
+
+func TestMixIniParentSectionKey(t *testing.T) {
+	type config struct {
+		Server struct {
+			TLS struct {
+				Host string `ini:"host"`
+				Cert string `ini:"cert"`
+			} `ini:"tls"`
+		} `ini:"server"`
+	}
+
+	// Key which is not defined in [server.tls] is inherited from [server]
+	var c config
+	err := twist.Mix(&c, twist.WithIniBytes([]byte("[server]\nhost = ini.localhost\n[server.tls]\ncert = server.crt\n")))
+	assert.NoError(t, err)
+	assert.Equal(t, "ini.localhost", c.Server.TLS.Host)
+	assert.Equal(t, "server.crt", c.Server.TLS.Cert)
+}
+
+func TestMixIniShadowKeys(t *testing.T) {
+	var config iniConfig
+	err := twist.Mix(&config, twist.WithIniBytes([]byte(strings.Join([]string{
+		"[server]",
+		"host = first.localhost",
+		"host = second.localhost",
+		"origin = https://example.com",
+		"origin = https://example.org",
+	}, "\n"))))
+	assert.NoError(t, err)
+	assert.Equal(t, "second.localhost", config.Server.Host)
+	assert.Equal(t, []string{"https://example.com", "https://example.org"}, config.Server.Origins)
+}
+
+func TestMixIniStrictNestedSection(t *testing.T) {
+	var config iniConfig
+	err := twist.Mix(&config,
+		twist.WithIniBytes([]byte("[server.tsl]\ncert = server.crt\n[server.tls]\ncret = server.crt\n")),
+		twist.Strict(),
+	)
+	var unknowns twist.UnknownKeyErrors
+	if assert.True(t, errors.As(err, &unknowns)) && assert.Len(t, unknowns, 2) {
+		assert.Equal(t, "server.tsl", unknowns[0].Key)
+		assert.Equal(t, "server.tls", unknowns[0].Suggestion)
+		assert.Equal(t, "server.tls.cret", unknowns[1].Key)
+		assert.Equal(t, "server.tls.cert", unknowns[1].Suggestion)
+	}
+}
+
+func TestMarshalIniNestedSection(t *testing.T) {
+	var expect iniConfig
+	expect.Token = "ini_token"
+	expect.Server.Host = "ini.localhost"
+	expect.Server.Port = 8080
+	expect.Server.Origins = []string{"https://example.com"}
+	expect.Server.TLS = &iniTLS{Cert: "server.crt", Key: "server.key"}
+
+	out, err := twist.Marshal(&expect, twist.FormatIni)
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), "[server.tls]")
+	assert.Contains(t, string(out), "; Path to certificate file")
+
+	var actual iniConfig
+	assert.NoError(t, twist.Mix(&actual, twist.WithIniReader(bytes.NewReader(out))))
+	assert.Equal(t, expect, actual)
+}
@@ -0,0 +1,95 @@
+package twist
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Check the type parameter is a struct, which is reported before cascading
+func checkStructType[T any]() error {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("Type parameter must be a struct, got %s", t)
+	}
+	return nil
+}
+
+// Load cascades configuration into the new value of T as same as Mix() and returns it.
+// T must be a struct type, not a pointer.
+//
+//	config, err := twist.Load[MyConfig](twist.WithToml("config.toml"), twist.WithEnv())
+func Load[T any](opts ...Option) (T, error) {
+	var v T
+	if err := checkStructType[T](); err != nil {
+		return v, err
+	}
+	if err := Mix(&v, opts...); err != nil {
+		return v, err
+	}
+	return v, nil
+}
+
+// MustLoad is like Load but panics if cascading is failed.
+// It simplifies initialization of global configuration.
+func MustLoad[T any](opts ...Option) T {
+	v, err := Load[T](opts...)
+	if err != nil {
+		panic("twist: " + err.Error())
+	}
+	return v
+}
+
+// Loader keeps the option list and loads configuration of T repeatedly,
+// which is useful for reloading and for tests which share the base options.
+type Loader[T any] struct {
+	opts []Option
+}
+
+// NewLoader creates Loader with options. It panics if T is not a struct type
+// so that the mistake is found at initialization rather than the first loading.
+func NewLoader[T any](opts ...Option) *Loader[T] {
+	if err := checkStructType[T](); err != nil {
+		panic("twist: " + err.Error())
+	}
+	return &Loader[T]{
+		opts: append([]Option{}, opts...),
+	}
+}
+
+// With returns new Loader which has additional options after the current ones.
+// The current Loader is not modified.
+func (l *Loader[T]) With(opts ...Option) *Loader[T] {
+	return &Loader[T]{
+		opts: append(append([]Option{}, l.opts...), opts...),
+	}
+}
+
+// Load runs the full cascade and returns new value of T
+func (l *Loader[T]) Load() (T, error) {
+	return Load[T](l.opts...)
+}
+
+// LoadWithReport runs the full cascade and returns new value of T with provenance report
+func (l *Loader[T]) LoadWithReport() (T, Report, error) {
+	var v T
+	report, err := MixWithReport(&v, l.opts...)
+	if err != nil {
+		return v, nil, err
+	}
+	return v, report, nil
+}
+
+// MustLoad is like Load but panics if cascading is failed
+func (l *Loader[T]) MustLoad() T {
+	v, err := l.Load()
+	if err != nil {
+		panic("twist: " + err.Error())
+	}
+	return v
+}
+
+// Watch creates Watcher which reloads configuration with the options
+func (l *Loader[T]) Watch() (*Watcher, error) {
+	var v T
+	return NewWatcher(&v, l.opts...)
+}
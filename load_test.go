@@ -0,0 +1,85 @@
+package twist_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	twist "github.com/ysugimoto/twist"
+)
+
+func TestLoad(t *testing.T) {
+	config, err := twist.Load[fixtureConfig](twist.WithToml("./fixtures/example.toml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "token_from_toml", config.Token)
+	assert.Equal(t, 9999, config.Server.Port)
+
+	_, err = twist.Load[*fixtureConfig]()
+	assert.EqualError(t, err, "Type parameter must be a struct, got *twist_test.fixtureConfig")
+
+	_, err = twist.Load[fixtureConfig](twist.WithToml("./fixtures/not_found.toml"))
+	assert.Error(t, err)
+}
+
+func TestMustLoad(t *testing.T) {
+	config := twist.MustLoad[struct {
+		Host string `default:"localhost"`
+	}]()
+	assert.Equal(t, "localhost", config.Host)
+
+	assert.Panics(t, func() {
+		twist.MustLoad[fixtureConfig](twist.WithToml("./fixtures/not_found.toml"))
+	})
+}
+
+func TestLoader(t *testing.T) {
+	loader := twist.NewLoader[fixtureConfig](twist.WithToml("./fixtures/example.toml"), twist.WithEnv())
+
+	config, err := loader.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "token_from_toml", config.Token)
+
+	// Loader can be re-run and reflects the current environment
+	t.Setenv("TWIST_TOKEN", "token_from_env")
+	config, report, err := loader.LoadWithReport()
+	assert.NoError(t, err)
+	assert.Equal(t, "token_from_env", config.Token)
+	p, ok := report.Lookup("Token")
+	assert.True(t, ok)
+	assert.Equal(t, "env", p.Source)
+
+	// With does not modify the original loader
+	overridden := loader.With(twist.WithToml("./fixtures/example.override.toml"))
+	assert.Equal(t, "toml.override.localhost", overridden.MustLoad().Server.Host)
+	assert.Equal(t, "toml.localhost", loader.MustLoad().Server.Host)
+
+	assert.Panics(t, func() {
+		twist.NewLoader[string]()
+	})
+}
+
+func TestLoaderConcurrentLoad(t *testing.T) {
+	t.Setenv("APP_TWIST_TOKEN", "token_from_env")
+	loader := twist.NewLoader[fixtureConfig](twist.WithEnv(), twist.WithEnvPrefix("APP"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			config, err := loader.Load()
+			assert.NoError(t, err)
+			assert.Equal(t, "token_from_env", config.Token)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMixWithNonStruct(t *testing.T) {
+	var config fixtureConfig
+	assert.EqualError(t, twist.Mix(config), "Cascading value must be a pointer to struct, got twist_test.fixtureConfig")
+
+	var s string
+	assert.Error(t, twist.Mix(&s))
+	assert.Error(t, twist.Mix(nil))
+}
@@ -0,0 +1,59 @@
+package twist
+
+import (
+	"context"
+	"log/slog"
+	"os"
+)
+
+// Actions of cascading events
+const (
+	actionAssigned  = "assigned"
+	actionAllocated = "allocated"
+	actionSkipped   = "skipped"
+	actionNotFound  = "not-found"
+)
+
+// Logger returns the logger which has current source attribute.
+// Custom sources can use it for logging in the same manner as builtin sources.
+func (c *Context) Logger() *slog.Logger {
+	logger := c.logger
+	if logger == nil {
+		logger = discardLogger
+	}
+	return logger.With("source", c.source)
+}
+
+// Log cascading event of the field in debug level
+func (c *Context) logEvent(action, path, key string, args ...any) {
+	if !c.debugEnabled() {
+		return
+	}
+	attrs := []any{"source", c.source, "field", path, "key", key, "action", action}
+	c.logger.Debug("twist: "+action, append(attrs, args...)...)
+}
+
+// Check debug logging is enabled, which is used to avoid building log attributes
+func (c *Context) debugEnabled() bool {
+	return c.logger != nil && c.logger.Enabled(context.Background(), slog.LevelDebug)
+}
+
+// Default logger outputs debug logs to stderr only if TWIST_DEBUG environment variable is defined,
+// otherwise logs are discarded
+func defaultLogger() *slog.Logger {
+	if os.Getenv("TWIST_DEBUG") != "" {
+		return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+	return discardLogger
+}
+
+// Logger which discards all logs, shared in order to avoid allocation on every cascading
+var discardLogger = slog.New(discardHandler{})
+
+// Handler which discards all logs
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
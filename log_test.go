@@ -0,0 +1,36 @@
+package twist_test
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	twist "github.com/ysugimoto/twist"
+)
+
+func TestMixWithLogger(t *testing.T) {
+	t.Setenv("LOG_TOKEN", "s3cr3t")
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	var config struct {
+		Token string `env:"LOG_TOKEN" secret:"true"`
+		Host  string `env:"LOG_HOST" default:"localhost"`
+	}
+	err := twist.Mix(&config, twist.WithEnv(), twist.WithLogger(logger))
+	assert.NoError(t, err)
+	assert.Equal(t, `level=DEBUG msg="twist: assigned" source=env field=Token key=LOG_TOKEN action=assigned value=******
+level=DEBUG msg="twist: not-found" source=env field=Host key=LOG_HOST action=not-found
+level=DEBUG msg="twist: assigned" source=default field=Host key="" action=assigned value=localhost
+`, buf.String())
+}
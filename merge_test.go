@@ -0,0 +1,140 @@
+package twist_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	twist "github.com/ysugimoto/twist"
+)
+
+type mergeTLS struct {
+	Cert string `toml:"cert" yaml:"cert" json:"cert"`
+	Key  string `toml:"key" yaml:"key" json:"key"`
+}
+
+type mergeBase struct {
+	Name    string `toml:"name" yaml:"name" json:"name"`
+	Version string `toml:"version" yaml:"version" json:"version"`
+}
+
+type mergeUpstream struct {
+	Host string `toml:"host" yaml:"host" json:"host"`
+	Port int    `toml:"port" yaml:"port" json:"port"`
+}
+
+type mergeConfig struct {
+	mergeBase `yaml:",inline"`
+	Server    struct {
+		Host string    `toml:"host" yaml:"host" json:"host"`
+		TLS  *mergeTLS `toml:"tls" yaml:"tls" json:"tls"`
+	} `toml:"server" yaml:"server" json:"server"`
+	Upstreams []mergeUpstream  `toml:"upstreams" yaml:"upstreams" json:"upstreams"`
+	Backends  []*mergeUpstream `toml:"backends" yaml:"backends" json:"backends"`
+}
+
+func TestMixMergePointerStruct(t *testing.T) {
+	var config mergeConfig
+	err := twist.Mix(&config,
+		twist.WithTomlBytes([]byte("[server.tls]\ncert = \"server.crt\"\nkey = \"server.key\"\n")),
+		twist.WithYamlBytes([]byte("server:\n  tls:\n    key: override.key\n")),
+	)
+	assert.NoError(t, err)
+	if assert.NotNil(t, config.Server.TLS) {
+		assert.Equal(t, "server.crt", config.Server.TLS.Cert)
+		assert.Equal(t, "override.key", config.Server.TLS.Key)
+	}
+}
+
+func TestMixMergeNilPointerStruct(t *testing.T) {
+	t.Run("not allocated without data", func(t *testing.T) {
+		var config mergeConfig
+		err := twist.Mix(&config, twist.WithJsonBytes([]byte(`{"server": {"host": "localhost"}}`)))
+		assert.NoError(t, err)
+		assert.Equal(t, "localhost", config.Server.Host)
+		assert.Nil(t, config.Server.TLS)
+	})
+
+	t.Run("null does not erase earlier layer", func(t *testing.T) {
+		var config mergeConfig
+		err := twist.Mix(&config,
+			twist.WithTomlBytes([]byte("[server.tls]\ncert = \"server.crt\"\n")),
+			twist.WithJsonBytes([]byte(`{"server": {"host": "localhost", "tls": null}}`)),
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, "localhost", config.Server.Host)
+		if assert.NotNil(t, config.Server.TLS) {
+			assert.Equal(t, "server.crt", config.Server.TLS.Cert)
+		}
+	})
+}
+
+func TestMixMergeEmbeddedStruct(t *testing.T) {
+	var config mergeConfig
+	err := twist.Mix(&config,
+		twist.WithTomlBytes([]byte("name = \"app\"\nversion = \"1.0.0\"\n")),
+		twist.WithJsonBytes([]byte(`{"version": "1.1.0"}`)),
+		twist.WithYamlBytes([]byte("version: 1.2.0\n")),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "app", config.Name)
+	assert.Equal(t, "1.2.0", config.Version)
+}
+
+func TestMixMergeStructSlice(t *testing.T) {
+	var config mergeConfig
+	report, err := twist.MixWithReport(&config,
+		twist.WithTomlBytes([]byte(`
+[[upstreams]]
+host = "a.example.com"
+port = 8080
+
+[[upstreams]]
+host = "b.example.com"
+port = 8081
+
+[[backends]]
+host = "c.example.com"
+port = 9090
+`)),
+		twist.WithYamlBytes([]byte("upstreams:\n  - host: x.example.com\nbackends:\n  - port: 9091\n  - host: d.example.com\n")),
+	)
+	assert.NoError(t, err)
+	// Length follows the later layer, and elements keep values which are not defined
+	assert.Equal(t, []mergeUpstream{{Host: "x.example.com", Port: 8080}}, config.Upstreams)
+	if assert.Len(t, config.Backends, 2) {
+		assert.Equal(t, mergeUpstream{Host: "c.example.com", Port: 9091}, *config.Backends[0])
+		assert.Equal(t, mergeUpstream{Host: "d.example.com"}, *config.Backends[1])
+	}
+
+	entry, ok := report.Lookup("Upstreams[0].Host")
+	if assert.True(t, ok) {
+		assert.Equal(t, "x.example.com", entry.Value)
+	}
+}
+
+func TestMarshalMergeConfig(t *testing.T) {
+	var expect mergeConfig
+	expect.Name = "app"
+	expect.Version = "1.0.0"
+	expect.Server.Host = "localhost"
+	expect.Server.TLS = &mergeTLS{Cert: "server.crt", Key: "server.key"}
+	expect.Upstreams = []mergeUpstream{{Host: "a.example.com", Port: 8080}}
+	expect.Backends = []*mergeUpstream{{Host: "b.example.com", Port: 9090}}
+
+	tests := []struct {
+		format twist.Format
+		option func([]byte) twist.Option
+	}{
+		{format: twist.FormatToml, option: twist.WithTomlBytes},
+		{format: twist.FormatYaml, option: twist.WithYamlBytes},
+		{format: twist.FormatJson, option: twist.WithJsonBytes},
+	}
+	for _, tt := range tests {
+		out, err := twist.Marshal(&expect, tt.format)
+		assert.NoError(t, err)
+
+		var actual mergeConfig
+		assert.NoError(t, twist.Mix(&actual, tt.option(out)), string(tt.format))
+		assert.Equal(t, expect, actual, string(tt.format))
+	}
+}
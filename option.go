@@ -1,71 +1,193 @@
 package twist
 
 import (
+	"bytes"
+	"io"
+	"io/fs"
+	"log/slog"
 	"os"
+	"strings"
 )
 
 const (
-	optionNameToml = "toml"
-	optionNameIni  = "ini"
-	optionNameYaml = "yaml"
-	optionNameJson = "json"
-	optionNameEnv  = "env"
-	optionNameCli  = "cli"
+	optionNameToml   = "toml"
+	optionNameIni    = "ini"
+	optionNameYaml   = "yaml"
+	optionNameJson   = "json"
+	optionNameEnv    = "env"
+	optionNameCli    = "cli"
+	optionNameDotenv = "dotenv"
 )
 
 // Cascading config options
+// Option has either a source to cascade or a setting which is applied to whole cascading.
 type Option struct {
-	name  string
-	value interface{}
+	source Source
+	apply  func(ctx *Context)
 }
 
 // Will cascade from Toml config file
 func WithToml(tomlPath string) Option {
 	return Option{
-		name:  optionNameToml,
-		value: tomlPath,
+		source: &tomlSource{fileContent{path: tomlPath}},
 	}
 }
 
+// Will cascade from Toml document which is read from r
+func WithTomlReader(r io.Reader) Option {
+	return Option{
+		source: &tomlSource{fileContent{reader: r}},
+	}
+}
+
+// Will cascade from Toml document bytes
+func WithTomlBytes(b []byte) Option {
+	return WithTomlReader(bytes.NewReader(b))
+}
+
 // Will cascade from ini config file
 func WithIni(iniPath string) Option {
 	return Option{
-		name:  optionNameIni,
-		value: iniPath,
+		source: &iniSource{fileContent{path: iniPath}},
+	}
+}
+
+// Will cascade from ini document which is read from r
+func WithIniReader(r io.Reader) Option {
+	return Option{
+		source: &iniSource{fileContent{reader: r}},
 	}
 }
 
+// Will cascade from ini document bytes
+func WithIniBytes(b []byte) Option {
+	return WithIniReader(bytes.NewReader(b))
+}
+
 // Will cascade from ini config file
 func WithYaml(yamlPath string) Option {
 	return Option{
-		name:  optionNameYaml,
-		value: yamlPath,
+		source: &yamlSource{fileContent{path: yamlPath}},
+	}
+}
+
+// Will cascade from yaml document which is read from r
+func WithYamlReader(r io.Reader) Option {
+	return Option{
+		source: &yamlSource{fileContent{reader: r}},
 	}
 }
 
+// Will cascade from yaml document bytes
+func WithYamlBytes(b []byte) Option {
+	return WithYamlReader(bytes.NewReader(b))
+}
+
 // Will cascade from JSON config file
 func WithJson(jsonPath string) Option {
 	return Option{
-		name:  optionNameJson,
-		value: jsonPath,
+		source: &jsonSource{fileContent{path: jsonPath}},
+	}
+}
+
+// Will cascade from JSON document which is read from r
+func WithJsonReader(r io.Reader) Option {
+	return Option{
+		source: &jsonSource{fileContent{reader: r}},
 	}
 }
 
+// Will cascade from JSON document bytes
+func WithJsonBytes(b []byte) Option {
+	return WithJsonReader(bytes.NewReader(b))
+}
+
 // Will cascade from Environment variables
 func WithEnv() Option {
 	return Option{
-		name:  optionNameEnv,
-		value: nil,
+		source: &envSource{},
+	}
+}
+
+// Will cascade from dotenv (.env) file with the same env tag mapping as WithEnv().
+// Variables are not exported to the process environment.
+func WithDotenv(dotenvPath string) Option {
+	return Option{
+		source: &dotenvSource{fileContent{path: dotenvPath}},
 	}
 }
 
+// Will cascade from dotenv document which is read from r
+func WithDotenvReader(r io.Reader) Option {
+	return Option{
+		source: &dotenvSource{fileContent{reader: r}},
+	}
+}
+
+// Will cascade from dotenv document bytes
+func WithDotenvBytes(b []byte) Option {
+	return WithDotenvReader(bytes.NewReader(b))
+}
+
 // Will cascade from command-line arguments
 func WithCli(args []string) Option {
 	if args == nil {
 		args = os.Args[1:]
 	}
 	return Option{
-		name:  optionNameCli,
-		value: args,
+		source: &cliSource{args: args},
+		apply: func(ctx *Context) {
+			ctx.args = args
+		},
+	}
+}
+
+// Will cascade from user defined source which implements Source interface
+func WithSource(source Source) Option {
+	return Option{
+		source: source,
+	}
+}
+
+// Will resolve all file paths (WithToml, WithYaml, WithJson, WithIni, WithDotenv) against the filesystem
+// like embed.FS instead of OS filesystem. Leading "/" and "./" of the path are trimmed.
+func WithFS(fsys fs.FS) Option {
+	return Option{
+		apply: func(ctx *Context) {
+			ctx.fs = fsys
+		},
+	}
+}
+
+// Will prefix environment variable names with the prefix like "MYAPP_PORT".
+// Separator "_" is added automatically if the prefix does not end with it.
+func WithEnvPrefix(prefix string) Option {
+	if prefix != "" && !strings.HasSuffix(prefix, "_") {
+		prefix += "_"
+	}
+	return Option{
+		apply: func(ctx *Context) {
+			ctx.envPrefix = prefix
+		},
+	}
+}
+
+// Will derive environment variable names from the struct path for the fields which don't have env tag.
+// For example, Server.Port field is mapped to SERVER_PORT (and MYAPP_SERVER_PORT with WithEnvPrefix("MYAPP")).
+func WithAutoEnv() Option {
+	return Option{
+		apply: func(ctx *Context) {
+			ctx.autoEnv = true
+		},
+	}
+}
+
+// Will output cascading events to the logger in debug level.
+// By default, logs are output to stderr only if TWIST_DEBUG environment variable is defined.
+func WithLogger(logger *slog.Logger) Option {
+	return Option{
+		apply: func(ctx *Context) {
+			ctx.logger = logger
+		},
 	}
 }
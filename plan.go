@@ -0,0 +1,188 @@
+package twist
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Compiled field plans for each root struct type, shared by all sources and Mix() calls
+var plans sync.Map
+
+// typePlan is the compiled struct type which holds dotted paths and parsed tags of fields,
+// so that sources don't need to walk the type, split tag strings and join paths on every cascading.
+// Nested struct is compiled for each position in the root struct because paths differ.
+type typePlan struct {
+	fields []*fieldPlan
+
+	// Boolean cli option names in the whole struct tree, which don't take a value
+	boolCliNames map[string]struct{}
+
+	// All cli option names in the whole struct tree for suggestion
+	cliNames []string
+}
+
+// fieldPlan is the compiled struct field
+type fieldPlan struct {
+	index int
+	field reflect.StructField
+
+	// Dotted field path from the root struct like "Server.Port"
+	path string
+
+	// Field can be set via reflection, or exported fields of the embedded struct can be set
+	exported bool
+
+	// Dereferenced field type
+	typ   reflect.Type
+	isPtr bool
+
+	// Compiled plan of the nested struct, nil if the field is leaf
+	nested *typePlan
+
+	// Field refers the struct type of its ancestor, which is not walked in order to avoid infinite recursion
+	cyclic bool
+
+	// Key names of file format tags like toml, yaml, json and ini, which are only set for valid tags
+	keys map[string]string
+
+	// Key paths from the root of file format tags like ["server", "port"],
+	// which are only set if all ancestors have the tag
+	keyPaths map[string][]string
+
+	// File format tags which the embedded struct is flattened into the parent on decoding
+	inline map[string]bool
+
+	// env and envPrefix tags
+	env         string
+	hasEnv      bool
+	envPrefix   string
+	hasPrefix   bool
+	autoEnvName string
+
+	// cli option names in the tag
+	cliNames []string
+
+	// default tag
+	defaultValue string
+	hasDefault   bool
+
+	// Compiled pattern tag
+	pattern    *regexp.Regexp
+	patternErr error
+}
+
+// Get compiled plan of the struct type, which is compiled at the first time
+func planOf(t reflect.Type) *typePlan {
+	t = derefType(t)
+	if p, ok := plans.Load(t); ok {
+		return p.(*typePlan)
+	}
+	p := compilePlan(t, "", nil, map[reflect.Type]struct{}{t: {}})
+	actual, _ := plans.LoadOrStore(t, p)
+	return actual.(*typePlan)
+}
+
+// Compile struct type recursively.
+// parent is the nested struct field which has the struct, nil for the root.
+// ancestors holds struct types on the way from the root in order to detect self-referencing types.
+func compilePlan(t reflect.Type, path string, parent *fieldPlan, ancestors map[reflect.Type]struct{}) *typePlan {
+	p := &typePlan{
+		boolCliNames: make(map[string]struct{}),
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		f := &fieldPlan{
+			index:    i,
+			field:    field,
+			path:     joinPath(path, field.Name),
+			exported: field.PkgPath == "" || isEmbeddedStruct(field),
+			typ:      derefType(field.Type),
+			isPtr:    field.Type.Kind() == reflect.Ptr,
+			keys:     make(map[string]string),
+			keyPaths: make(map[string][]string),
+		}
+		for _, name := range []string{tagNameToml, tagNameYaml, tagNameJson, tagNameIni} {
+			if isInlineField(field, name) {
+				if f.inline == nil {
+					f.inline = make(map[string]bool)
+				}
+				f.inline[name] = true
+				continue
+			}
+			tag, ok := field.Tag.Lookup(name)
+			if !ok || tagKeyName(tag) == "" || tag == "-" {
+				continue
+			}
+			f.keys[name] = tagKeyName(tag)
+			if parent == nil {
+				f.keyPaths[name] = []string{f.keys[name]}
+			} else if parentKeys, ok := parent.keyPaths[name]; ok {
+				f.keyPaths[name] = append(append(make([]string, 0, len(parentKeys)+1), parentKeys...), f.keys[name])
+			}
+		}
+		f.env, f.hasEnv = field.Tag.Lookup(tagNameEnv)
+		f.envPrefix, f.hasPrefix = field.Tag.Lookup(tagNameEnvPrefix)
+		f.autoEnvName = toSnakeCase(field.Name)
+		if tag, ok := field.Tag.Lookup(tagNameCli); ok && tag != "" && tag != "-" {
+			for _, name := range strings.Split(tag, ",") {
+				f.cliNames = append(f.cliNames, strings.TrimSpace(name))
+			}
+		}
+		if tag, ok := field.Tag.Lookup(tagNameDefault); ok && tag != "" && tag != "-" {
+			f.defaultValue, f.hasDefault = tag, true
+		}
+		if tag, ok := field.Tag.Lookup(tagNamePattern); ok {
+			f.pattern, f.patternErr = regexp.Compile(tag)
+		}
+		p.fields = append(p.fields, f)
+
+		if !f.exported {
+			continue
+		}
+		if isNestedStruct(f.typ) {
+			if _, ok := ancestors[f.typ]; ok {
+				f.cyclic = true
+				continue
+			}
+			ancestors[f.typ] = struct{}{}
+			f.nested = compilePlan(f.typ, f.path, f, ancestors)
+			delete(ancestors, f.typ)
+
+			for name := range f.nested.boolCliNames {
+				p.boolCliNames[name] = struct{}{}
+			}
+			p.cliNames = append(p.cliNames, f.nested.cliNames...)
+			continue
+		}
+		if f.typ.Kind() == reflect.Bool {
+			for _, name := range f.cliNames {
+				p.boolCliNames[name] = struct{}{}
+			}
+		}
+		p.cliNames = append(p.cliNames, f.cliNames...)
+	}
+	return p
+}
+
+// Check the embedded struct field is flattened into the parent on decoding.
+// toml and encoding/json flatten embedded struct which doesn't have the key name in the tag,
+// and yaml flattens the one which has inline flag like `yaml:",inline"`.
+func isInlineField(field reflect.StructField, tagName string) bool {
+	if !field.Anonymous || !isNestedStruct(derefType(field.Type)) {
+		return false
+	}
+	tag, ok := field.Tag.Lookup(tagName)
+	if tagName != tagNameToml && tagName != tagNameJson {
+		return ok && strings.Contains(tag, ",inline")
+	}
+	return tag != "-" && tagKeyName(tag) == ""
+}
+
+// Check the field is embedded struct which is not a pointer.
+// Exported fields of the struct can be set via reflection even if the struct type is unexported.
+func isEmbeddedStruct(field reflect.StructField) bool {
+	return field.Anonymous && field.Type.Kind() == reflect.Struct && isNestedStruct(field.Type)
+}
@@ -0,0 +1,104 @@
+package twist_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	twist "github.com/ysugimoto/twist"
+)
+
+type benchConfig struct {
+	Token   string        `toml:"token" env:"BENCH_TOKEN" cli:"t,token" secret:"true"`
+	Debug   bool          `toml:"debug" env:"BENCH_DEBUG" cli:"d,debug"`
+	Timeout time.Duration `toml:"timeout" env:"BENCH_TIMEOUT" default:"30s"`
+	Labels  []string      `env:"BENCH_LABELS" cli:"label"`
+	Server  struct {
+		Host string `toml:"host" env:"BENCH_HOST" cli:"host" default:"localhost"`
+		Port int    `toml:"port" env:"BENCH_PORT" cli:"p,port" default:"8080"`
+		TLS  struct {
+			Cert string `toml:"cert" env:"BENCH_TLS_CERT"`
+			Key  string `toml:"key" env:"BENCH_TLS_KEY"`
+		} `toml:"tls"`
+	} `toml:"server"`
+	Database struct {
+		DSN     string `toml:"dsn" env:"BENCH_DSN" cli:"dsn"`
+		MaxConn int    `toml:"max_conn" env:"BENCH_MAX_CONN" default:"10"`
+	} `toml:"database"`
+}
+
+type nodeConfig struct {
+	Name string      `env:"TWIST_NODE_NAME" cli:"name" default:"root"`
+	Next *nodeConfig `toml:"next"`
+}
+
+func TestMixWithSelfReference(t *testing.T) {
+	t.Setenv("TWIST_NODE_NAME", "node")
+	var config nodeConfig
+	err := twist.Mix(&config, twist.WithEnv(), twist.WithCli([]string{}))
+	assert.NoError(t, err)
+	assert.Equal(t, "node", config.Name)
+	assert.Nil(t, config.Next)
+
+	usage := twist.Usage(&config)
+	assert.Contains(t, usage, "--name string")
+	assert.NotContains(t, usage, "Next Options:")
+}
+
+var benchToml = []byte(`
+token = "token"
+timeout = "10s"
+
+[server]
+host = "example.com"
+
+[server.tls]
+cert = "/path/to/cert"
+
+[database]
+dsn = "postgres://localhost"
+`)
+
+func benchmarkMix(b *testing.B, cached bool, opts func() []twist.Option) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if !cached {
+			twist.ResetPlanCache()
+		}
+		var config benchConfig
+		if err := twist.Mix(&config, opts()...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMix(b *testing.B) {
+	b.Setenv("BENCH_PORT", "9090")
+	b.Setenv("BENCH_LABELS", "a,b,c")
+	args := []string{"-d", "--host", "cli.example.com", "--label", "x"}
+	opts := func() []twist.Option {
+		return []twist.Option{twist.WithTomlBytes(benchToml), twist.WithEnv(), twist.WithCli(args)}
+	}
+
+	b.Run("cached", func(b *testing.B) {
+		benchmarkMix(b, true, opts)
+	})
+	b.Run("uncached", func(b *testing.B) {
+		benchmarkMix(b, false, opts)
+	})
+}
+
+func BenchmarkMixEnvAndCli(b *testing.B) {
+	b.Setenv("BENCH_PORT", "9090")
+	args := []string{"-d", "--host", "cli.example.com"}
+	opts := func() []twist.Option {
+		return []twist.Option{twist.WithEnv(), twist.WithCli(args)}
+	}
+
+	b.Run("cached", func(b *testing.B) {
+		benchmarkMix(b, true, opts)
+	})
+	b.Run("uncached", func(b *testing.B) {
+		benchmarkMix(b, false, opts)
+	})
+}
@@ -0,0 +1,126 @@
+package twist
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"reflect"
+	"sort"
+	"text/tabwriter"
+)
+
+const sourceNameDefault = "default"
+
+// Context holds the state of a cascading process and is passed to each Source.
+type Context struct {
+	// Current cascading source name
+	source string
+
+	// Provenance report, nil if caller does not need it
+	report Report
+
+	// Logger for cascading events
+	logger *slog.Logger
+
+	// Filesystem which file paths are resolved against
+	fs fs.FS
+
+	// Environment variable naming settings
+	envPrefix string
+	autoEnv   bool
+
+	// Command-line arguments of WithCli() and option names which are consumed by WithConfigFileFlag()
+	args        []string
+	configFlags map[string]struct{}
+
+	// Reject unknown keys in files
+	strict bool
+}
+
+// Record tells that the field of path (dotted field names like "Server.Port") has been assigned
+// from location of the current source, location is file path, environment variable name, cli flag, etc.
+// Custom sources should call this after assigning a value in order to appear in provenance report and logs.
+func (c *Context) Record(path, location, value string) {
+	if c == nil {
+		return
+	}
+	c.logEvent(actionAssigned, path, location, "value", value)
+	if c.report == nil {
+		return
+	}
+	c.report[path] = append(c.report[path], &Provenance{
+		Source:   c.source,
+		Location: location,
+		Value:    value,
+	})
+}
+
+// Provenance represents where the field value came from
+type Provenance struct {
+	// Kind of source like "toml", "env", "cli", "default"
+	Source string
+
+	// File path, environment variable name, cli flag, etc
+	Location string
+
+	// Raw value string
+	Value string
+}
+
+// Report is provenance map keyed by dotted field path like "Server.Port".
+// Each history is stored in cascading order, so the last one is the effective value
+// and the others were overridden by following sources.
+type Report map[string][]*Provenance
+
+// Lookup returns effective provenance of the field
+func (r Report) Lookup(path string) (*Provenance, bool) {
+	history, ok := r[path]
+	if !ok || len(history) == 0 {
+		return nil, false
+	}
+	return history[len(history)-1], true
+}
+
+// Explain returns human readable cascading history for each field
+func (r Report) Explain() string {
+	paths := make([]string, 0, len(r))
+	for path := range r {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	for _, path := range paths {
+		history := r[path]
+		fmt.Fprintf(w, "%s\n", path)
+		for i := len(history) - 1; i >= 0; i-- {
+			p := history[i]
+			fmt.Fprintf(w, "  %s\t%s\t%s", p.Source, p.Location, p.Value)
+			if i != len(history)-1 {
+				fmt.Fprint(w, "\t(overridden)")
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// Join parent field path and field name
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// Format reflect value as string for the report
+func formatValue(v reflect.Value) string {
+	v = derefValue(v)
+	if !v.IsValid() {
+		return ""
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,149 @@
+package twist
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+// Optional wraps the file option to skip cascading silently if the file does not exist.
+// Unreadable or malformed files are still reported as errors.
+// Options which don't read files, or already skip missing files like WithSearchPath(), are returned as they are.
+func Optional(opt Option) Option {
+	if _, ok := opt.source.(fileSource); !ok {
+		return opt
+	}
+	if _, ok := opt.source.(*searchSource); ok {
+		return opt
+	}
+	return Option{
+		source: &optionalSource{source: opt.source},
+		apply:  opt.apply,
+	}
+}
+
+// Source which skips cascading if the files do not exist
+type optionalSource struct {
+	source Source
+}
+
+func (s *optionalSource) Name() string {
+	return s.source.Name()
+}
+
+// Returns files of the wrapped source in order to watch their creation
+func (s *optionalSource) files(ctx *Context) []string {
+	return s.source.(fileSource).files(ctx)
+}
+
+func (s *optionalSource) Cascade(ctx *Context, v reflect.Value) error {
+	for _, file := range s.files(ctx) {
+		if _, err := ctx.statFile(file); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				ctx.logEvent(actionSkipped, "", file, "reason", "file not exists")
+				return nil
+			}
+			return fmt.Errorf("file stat error: %w", err)
+		}
+	}
+	return s.source.Cascade(ctx, v)
+}
+
+// Will cascade every found file of the name in the search paths, in priority order from low to high:
+//
+//	/etc/<app>/<name>
+//	$XDG_CONFIG_DIRS/<app>/<name> (default /etc/xdg, later directories have low priority)
+//	$XDG_CONFIG_HOME/<app>/<name> (default ~/.config)
+//	<executable directory>/<name>
+//	<working directory>/<name>
+//
+// with is the file option constructor like WithToml which is called for each found file.
+func WithSearchPath(app, name string, with func(path string) Option) Option {
+	return Option{
+		source: &searchSource{
+			name:  with(name).source.Name(),
+			paths: SearchPaths(app, name),
+			with:  with,
+		},
+	}
+}
+
+// SearchPaths returns candidate file paths of WithSearchPath() in priority order from low to high
+func SearchPaths(app, name string) []string {
+	var dirs []string
+	dirs = append(dirs, filepath.Join("/etc", app))
+
+	configDirs := filepath.SplitList(os.Getenv("XDG_CONFIG_DIRS"))
+	if len(configDirs) == 0 {
+		configDirs = []string{"/etc/xdg"}
+	}
+	for i := len(configDirs) - 1; i >= 0; i-- {
+		if configDirs[i] != "" {
+			dirs = append(dirs, filepath.Join(configDirs[i], app))
+		}
+	}
+
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
+		dirs = append(dirs, filepath.Join(configHome, app))
+	} else if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".config", app))
+	}
+
+	if exe, err := os.Executable(); err == nil {
+		dirs = append(dirs, filepath.Dir(exe))
+	}
+	if wd, err := os.Getwd(); err == nil {
+		dirs = append(dirs, wd)
+	}
+
+	var paths []string
+	seen := make(map[string]struct{})
+	for _, dir := range dirs {
+		p := filepath.Join(dir, name)
+		if _, ok := seen[p]; ok {
+			continue
+		}
+		seen[p] = struct{}{}
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+// Source which cascades files found in the search paths
+type searchSource struct {
+	name  string
+	paths []string
+	with  func(path string) Option
+}
+
+func (s *searchSource) Name() string {
+	return s.name
+}
+
+// Returns all candidate paths in order to watch their creation
+func (s *searchSource) files(ctx *Context) []string {
+	return s.paths
+}
+
+func (s *searchSource) Cascade(ctx *Context, v reflect.Value) error {
+	for _, p := range s.paths {
+		info, err := ctx.statFile(p)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				ctx.logEvent(actionNotFound, "", p)
+				continue
+			}
+			return fmt.Errorf("file stat error: %w", err)
+		}
+		if info.IsDir() {
+			continue
+		}
+		if err := s.with(p).source.Cascade(ctx, v); err != nil {
+			return fmt.Errorf("Failed to cascade %s: %w", p, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package twist_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	twist "github.com/ysugimoto/twist"
+)
+
+func TestOptional(t *testing.T) {
+	config := struct {
+		Server struct {
+			Host string `toml:"host"`
+			Port int    `toml:"port"`
+		} `toml:"server"`
+	}{}
+	err := twist.Mix(
+		&config,
+		twist.WithToml("./fixtures/example.toml"),
+		twist.Optional(twist.WithToml("./fixtures/not_found.toml")),
+		twist.Optional(twist.WithToml("./fixtures/example.override.toml")),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "toml.override.localhost", config.Server.Host)
+	assert.Equal(t, 9999, config.Server.Port)
+
+	// Malformed file is still an error
+	err = twist.Mix(&config, twist.Optional(twist.WithToml("./fixtures/example.yaml")))
+	assert.Error(t, err)
+}
+
+func TestWithSearchPath(t *testing.T) {
+	home := t.TempDir()
+	sys := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("XDG_CONFIG_DIRS", sys)
+
+	write := func(dir, content string) {
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, "twist"), 0o755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "twist", "search.toml"), []byte(content), 0o644))
+	}
+	write(sys, "host = \"system.localhost\"\nport = 8080\n")
+	write(home, "host = \"home.localhost\"\n")
+
+	paths := twist.SearchPaths("twist", "search.toml")
+	assert.Contains(t, paths, filepath.Join(sys, "twist", "search.toml"))
+	assert.Contains(t, paths, filepath.Join(home, "twist", "search.toml"))
+
+	config := struct {
+		Host string `toml:"host"`
+		Port int    `toml:"port"`
+	}{}
+	report, err := twist.MixWithReport(&config, twist.WithSearchPath("twist", "search.toml", twist.WithToml))
+	assert.NoError(t, err)
+	assert.Equal(t, "home.localhost", config.Host)
+	assert.Equal(t, 8080, config.Port)
+
+	p, ok := report.Lookup("Port")
+	assert.True(t, ok)
+	assert.Equal(t, "toml", p.Source)
+	assert.Equal(t, filepath.Join(sys, "twist", "search.toml"), p.Location)
+
+	// Optional does not skip the search path even though some candidates do not exist
+	config.Host, config.Port = "", 0
+	err = twist.Mix(&config, twist.Optional(twist.WithSearchPath("twist", "search.toml", twist.WithToml)))
+	assert.NoError(t, err)
+	assert.Equal(t, "home.localhost", config.Host)
+	assert.Equal(t, 8080, config.Port)
+}
@@ -0,0 +1,114 @@
+package twist
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const tagNameSecret = "secret"
+
+// Masked string for the secret values
+const secretMask = "******"
+
+// Check the field is marked as secret by `secret:"true"` tag
+func isSecret(field reflect.StructField) bool {
+	return field.Tag.Get(tagNameSecret) == "true"
+}
+
+// Mask the value if the field is secret
+func maskValue(field reflect.StructField, value string) string {
+	if isSecret(field) {
+		return secretMask
+	}
+	return value
+}
+
+// Mask secret values in the error message.
+// Note that the original error is not wrapped in order not to leak the values via Unwrap().
+func maskError(field reflect.StructField, err error, values ...string) error {
+	if err == nil || !isSecret(field) {
+		return err
+	}
+	message := err.Error()
+	for _, v := range values {
+		if v != "" {
+			message = strings.ReplaceAll(message, v, secretMask)
+		}
+	}
+	return errors.New(message)
+}
+
+// Mask secret values which are defined in the decoded document in the decode error,
+// because decoders like yaml include the raw value in the error message.
+// The original error is kept if it does not contain any secret value.
+func maskDecodeError(err error, tagName string, plan *typePlan, keys interface{}) error {
+	message := err.Error()
+	masked := message
+	for _, v := range secretValues(tagName, plan, keys, nil) {
+		if v != "" {
+			masked = strings.ReplaceAll(masked, v, secretMask)
+		}
+	}
+	if masked == message {
+		return err
+	}
+	return errors.New(masked)
+}
+
+// Collect values of secret fields in the decoded document by walking the plan
+func secretValues(tagName string, plan *typePlan, m interface{}, values []string) []string {
+	for _, f := range plan.fields {
+		if !f.exported || f.cyclic {
+			continue
+		}
+		if f.inline[tagName] && f.nested != nil {
+			values = secretValues(tagName, f.nested, m, values)
+			continue
+		}
+		key, ok := f.keys[tagName]
+		if !ok {
+			continue
+		}
+		value, ok := lookupFieldKey(m, key, tagName)
+		if !ok {
+			continue
+		}
+		switch {
+		case isSecret(f.field):
+			values = appendScalars(values, value)
+		case f.nested != nil:
+			values = secretValues(tagName, f.nested, value, values)
+		case isStructSlice(f.field.Type):
+			if rv := reflect.ValueOf(value); rv.Kind() == reflect.Slice {
+				elemPlan := planOf(f.field.Type.Elem())
+				for i := 0; i < rv.Len(); i++ {
+					values = secretValues(tagName, elemPlan, rv.Index(i).Interface(), values)
+				}
+			}
+		}
+	}
+	return values
+}
+
+// Append scalar values in the decoded value, elements of list and map are appended recursively
+func appendScalars(values []string, v interface{}) []string {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return values
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			values = appendScalars(values, rv.Index(i).Interface())
+		}
+	case reflect.Map:
+		iter := rv.MapRange()
+		for iter.Next() {
+			values = appendScalars(values, iter.Value().Interface())
+		}
+	default:
+		values = append(values, fmt.Sprint(v))
+	}
+	return values
+}
@@ -0,0 +1,96 @@
+package twist_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	twist "github.com/ysugimoto/twist"
+)
+
+func TestSecretMaskedInError(t *testing.T) {
+	t.Setenv("SECRET_PIN", "s3cr3t")
+
+	var config struct {
+		Pin int `env:"SECRET_PIN" secret:"true"`
+	}
+	err := twist.Mix(&config, twist.WithEnv())
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "s3cr3t")
+	assert.Contains(t, err.Error(), "******")
+
+	// yaml decoder includes the raw value in the error
+	var file struct {
+		Server struct {
+			Pin int `yaml:"pin" secret:"true"`
+		} `yaml:"server"`
+	}
+	err = twist.Mix(&file, twist.WithYamlBytes([]byte("server:\n  pin: s3cr3t\n")))
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "s3cr3t")
+	assert.Contains(t, err.Error(), "******")
+}
+
+func TestSecretMaskedInOutputs(t *testing.T) {
+	t.Setenv("SECRET_TOKEN", "s3cr3t")
+
+	var config struct {
+		Token string `env:"SECRET_TOKEN" json:"token" cli:"token" secret:"true"`
+		Key   string `cli:"key" default:"k3y" secret:"true"`
+		Host  string `json:"host" default:"localhost"`
+	}
+	report, err := twist.MixWithReport(&config, twist.WithEnv())
+	assert.NoError(t, err)
+	// Real value is still assigned
+	assert.Equal(t, "s3cr3t", config.Token)
+	assert.Equal(t, "k3y", config.Key)
+
+	p, ok := report.Lookup("Token")
+	assert.True(t, ok)
+	assert.Equal(t, "******", p.Value)
+	assert.NotContains(t, report.Explain(), "s3cr3t")
+
+	out, err := twist.Marshal(&config, twist.FormatJson)
+	assert.NoError(t, err)
+	assert.Equal(t, "{\n  \"token\": \"******\",\n  \"host\": \"localhost\"\n}\n", string(out))
+
+	args, err := twist.MarshalArgs(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--token=******", "--key=******"}, args)
+
+	assert.NotContains(t, twist.Usage(&config), "k3y")
+}
+
+func TestSecretMaskedInStructCollection(t *testing.T) {
+	type upstream struct {
+		Host     string `toml:"host" yaml:"host" json:"host"`
+		Password string `toml:"password" yaml:"password" json:"password" secret:"true"`
+	}
+	var config struct {
+		Upstreams []upstream           `toml:"upstreams" yaml:"upstreams" json:"upstreams"`
+		Backends  map[string]*upstream `toml:"backends" yaml:"backends" json:"backends"`
+	}
+	report, err := twist.MixWithReport(&config, twist.WithJsonBytes([]byte(`{
+  "upstreams": [{"host": "a.example.com", "password": "hunter2"}],
+  "backends": {"primary": {"host": "b.example.com", "password": "hunter2"}}
+}`)))
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", config.Upstreams[0].Password)
+	assert.NotContains(t, report.Explain(), "hunter2")
+
+	p, ok := report.Lookup("Upstreams[0].Password")
+	if assert.True(t, ok) {
+		assert.Equal(t, "******", p.Value)
+	}
+	p, ok = report.Lookup("Backends[primary].Host")
+	if assert.True(t, ok) {
+		assert.Equal(t, "b.example.com", p.Value)
+	}
+
+	for _, format := range []twist.Format{twist.FormatToml, twist.FormatYaml, twist.FormatJson} {
+		out, err := twist.Marshal(&config, format)
+		assert.NoError(t, err)
+		assert.NotContains(t, string(out), "hunter2", string(format))
+		assert.Contains(t, string(out), "a.example.com", string(format))
+		assert.Contains(t, string(out), "b.example.com", string(format))
+	}
+}
@@ -0,0 +1,245 @@
+package twist
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-ini/ini"
+)
+
+// Source is the interface of cascading configuration source.
+// All builtin sources like toml, yaml, env, cli are implemented on this interface,
+// and you can implement your own source (e.g. Vault, KV store, database table)
+// and pass it to Mix() via WithSource() option.
+type Source interface {
+	// Name returns the kind of source like "toml", "env", which is used for error messages.
+	Name() string
+
+	// Cascade walks destination struct and assigns values from the source.
+	// v is dereferenced and settable struct value so the implementation can assign values via reflection,
+	// and ctx should be notified assigned fields via Record() for the provenance report.
+	Cascade(ctx *Context, v reflect.Value) error
+}
+
+// fileSource is implemented by sources which read files, used for watching file changes
+type fileSource interface {
+	files(ctx *Context) []string
+}
+
+// Content of file source which is read from the path, io.Reader or bytes
+type fileContent struct {
+	path   string
+	reader io.Reader
+	data   []byte
+	mu     sync.Mutex
+}
+
+// Read content. The path is resolved against fs.FS if WithFS() is specified.
+// Reader is read only once and cached so that the source can be cascaded repeatedly.
+func (c *fileContent) read(ctx *Context) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.reader != nil {
+		data, err := io.ReadAll(c.reader)
+		if err != nil {
+			return nil, err
+		}
+		c.data = data
+		c.reader = nil
+	}
+	if c.data != nil {
+		return c.data, nil
+	}
+	return ctx.readFile(c.path)
+}
+
+// Location for the report and error messages
+func (c *fileContent) location() string {
+	if c.path == "" {
+		return "(reader)"
+	}
+	return c.path
+}
+
+func (c *fileContent) files(ctx *Context) []string {
+	if c.path == "" {
+		return nil
+	}
+	return []string{c.path}
+}
+
+// Read file from the filesystem specified by WithFS(), or from OS filesystem
+func (c *Context) readFile(name string) ([]byte, error) {
+	if c.fs == nil {
+		return os.ReadFile(name)
+	}
+	return fs.ReadFile(c.fs, fsPath(name))
+}
+
+// Stat file on the filesystem specified by WithFS(), or on OS filesystem
+func (c *Context) statFile(name string) (fs.FileInfo, error) {
+	if c.fs == nil {
+		return os.Stat(name)
+	}
+	return fs.Stat(c.fs, fsPath(name))
+}
+
+// Convert file path to fs.FS path which is slash separated and unrooted
+func fsPath(name string) string {
+	return strings.TrimPrefix(path.Clean(filepath.ToSlash(name)), "/")
+}
+
+// Toml file source
+type tomlSource struct {
+	fileContent
+}
+
+func (s *tomlSource) Name() string {
+	return optionNameToml
+}
+
+func (s *tomlSource) Cascade(ctx *Context, v reflect.Value) error {
+	buf, err := s.read(ctx)
+	if err != nil {
+		return &SourceError{Source: s.Name(), Location: s.location(), Err: err}
+	}
+	return cascadeToml(ctx, s.location(), buf, v, reflect.New(v.Type()))
+}
+
+// Yaml file source
+type yamlSource struct {
+	fileContent
+}
+
+func (s *yamlSource) Name() string {
+	return optionNameYaml
+}
+
+func (s *yamlSource) Cascade(ctx *Context, v reflect.Value) error {
+	buf, err := s.read(ctx)
+	if err != nil {
+		return &SourceError{Source: s.Name(), Location: s.location(), Err: err}
+	}
+	return cascadeYaml(ctx, s.location(), buf, v, reflect.New(v.Type()))
+}
+
+// JSON file source
+type jsonSource struct {
+	fileContent
+}
+
+func (s *jsonSource) Name() string {
+	return optionNameJson
+}
+
+func (s *jsonSource) Cascade(ctx *Context, v reflect.Value) error {
+	buf, err := s.read(ctx)
+	if err != nil {
+		return &SourceError{Source: s.Name(), Location: s.location(), Err: err}
+	}
+	return cascadeJson(ctx, s.location(), buf, v, reflect.New(v.Type()))
+}
+
+// Ini file source
+type iniSource struct {
+	fileContent
+}
+
+func (s *iniSource) Name() string {
+	return optionNameIni
+}
+
+func (s *iniSource) Cascade(ctx *Context, v reflect.Value) error {
+	buf, err := s.read(ctx)
+	if err != nil {
+		return &SourceError{Source: s.Name(), Location: s.location(), Err: err}
+	}
+	src, err := ini.LoadSources(ini.LoadOptions{AllowShadows: true}, buf)
+	if err != nil {
+		return &SourceError{Source: s.Name(), Location: s.location(), Err: err}
+	}
+	if ctx.strict {
+		if err := strictIni(s.location(), buf, v.Type(), src); err != nil {
+			return err
+		}
+	}
+	return cascadeIni(ctx, s.location(), src, v, planOf(v.Type()), "")
+}
+
+// Dotenv file source
+type dotenvSource struct {
+	fileContent
+}
+
+func (s *dotenvSource) Name() string {
+	return optionNameDotenv
+}
+
+func (s *dotenvSource) Cascade(ctx *Context, v reflect.Value) error {
+	buf, err := s.read(ctx)
+	if err != nil {
+		return &SourceError{Source: s.Name(), Location: s.location(), Err: err}
+	}
+	values, err := parseDotenv(string(buf))
+	if err != nil {
+		return &SourceError{Source: s.Name(), Location: s.location(), Err: err}
+	}
+	return cascadeEnv(ctx, s.location(), func(name string) (string, bool) {
+		v, ok := values[name]
+		return v, ok
+	}, v, planOf(v.Type()), "")
+}
+
+// Environment variables source
+type envSource struct{}
+
+func (s *envSource) Name() string {
+	return optionNameEnv
+}
+
+func (s *envSource) Cascade(ctx *Context, v reflect.Value) error {
+	return cascadeEnv(ctx, "", os.LookupEnv, v, planOf(v.Type()), "")
+}
+
+// Command-line arguments source
+type cliSource struct {
+	args []string
+}
+
+func (s *cliSource) Name() string {
+	return optionNameCli
+}
+
+func (s *cliSource) Cascade(ctx *Context, v reflect.Value) error {
+	options, parsed := parseCliArgs(v, s.args)
+	cloned := make(map[string][]string)
+	for key, val := range options {
+		// Config file option is consumed by WithConfigFileFlag()
+		if _, ok := ctx.configFlags[key]; ok {
+			continue
+		}
+		cloned[key] = val
+	}
+	plan := planOf(v.Type())
+
+	// Help option is recognized automatically unless the struct defines it.
+	// It is checked before assigning so that malformed options don't hide the help request.
+	for _, name := range helpOptionNames {
+		if _, ok := cloned[name]; ok && !slices.Contains(plan.cliNames, name) {
+			return ErrHelp
+		}
+	}
+	if err := cascadeCli(ctx, v, options, cloned, plan); err != nil {
+		return err
+	}
+	// Remaining options are unrecognized
+	return unknownOptionErrors(ctx, v.Type(), parsed, cloned)
+}
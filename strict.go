@@ -0,0 +1,513 @@
+package twist
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-ini/ini"
+)
+
+// UnknownKeyError represents a key in the file which does not correspond to any field in strict mode
+type UnknownKeyError struct {
+	// Source kind like "toml", "yaml"
+	Source string
+
+	// File path, or "(reader)" for reader and bytes sources
+	File string
+
+	// Line number in the file, zero if unknown
+	Line int
+
+	// Dotted key like "server.prot"
+	Key string
+
+	// Most similar known key like "server.port", empty if there is no similar one
+	Suggestion string
+}
+
+func (e *UnknownKeyError) Error() string {
+	location := e.File
+	if e.Line > 0 {
+		location = fmt.Sprintf("%s:%d", e.File, e.Line)
+	}
+	message := fmt.Sprintf("%s: unknown %s key %q", location, e.Source, e.Key)
+	if e.Suggestion != "" {
+		message += fmt.Sprintf(", did you mean %q?", e.Suggestion)
+	}
+	return message
+}
+
+// UnknownKeyErrors aggregates all unknown keys found in the file
+type UnknownKeyErrors []*UnknownKeyError
+
+func (e UnknownKeyErrors) Error() string {
+	messages := make([]string, len(e))
+	for i := range e {
+		messages[i] = e[i].Error()
+	}
+	return strings.Join(messages, ", ")
+}
+
+// Unwrap returns each unknown key so that errors.As() can find *UnknownKeyError
+func (e UnknownKeyErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i := range e {
+		errs[i] = e[i]
+	}
+	return errs
+}
+
+// Will reject keys in toml, yaml, json and ini files which do not correspond to any tagged field.
+// All unknown keys are reported with the file and line number, and suggestion of the similar key.
+func Strict() Option {
+	return Option{
+		apply: func(ctx *Context) {
+			ctx.strict = true
+		},
+	}
+}
+
+// Collect unknown keys and build the error which is sorted by line number
+func unknownKeyErrors(source, file string, lines map[string]int, unknowns [][]string, suggestions []string) error {
+	if len(unknowns) == 0 {
+		return nil
+	}
+	errs := make(UnknownKeyErrors, len(unknowns))
+	for i, keys := range unknowns {
+		key := strings.Join(keys, ".")
+		errs[i] = &UnknownKeyError{
+			Source:     source,
+			File:       file,
+			Line:       lines[key],
+			Key:        key,
+			Suggestion: suggestions[i],
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Line != errs[j].Line {
+			return errs[i].Line < errs[j].Line
+		}
+		return errs[i].Key < errs[j].Key
+	})
+	return errs
+}
+
+// Suggest the similar key which has the same parent keys
+func suggestKey(keys, known []string) string {
+	found := suggest(keys[len(keys)-1], known)
+	if found == "" {
+		return ""
+	}
+	return strings.Join(append(append([]string{}, keys[:len(keys)-1]...), found), ".")
+}
+
+// Collect key names of tagged fields, which are mapped to the field type.
+// Embedded struct is flattened as same as the decoders do.
+func structKeys(t reflect.Type, tagName string) map[string]reflect.Type {
+	t = derefType(t)
+	keys := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if isInlineField(field, tagName) {
+			for k, ft := range structKeys(field.Type, tagName) {
+				keys[k] = ft
+			}
+			continue
+		}
+		tag, ok := field.Tag.Lookup(tagName)
+		if !ok || tag == "-" {
+			continue
+		}
+		if name := tagKeyName(tag); name != "" {
+			keys[name] = field.Type
+		}
+	}
+	return keys
+}
+
+// Sorted key names for suggestion
+func sortedKeys(keys map[string]reflect.Type) []string {
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Find known key names on the parent keys
+func knownKeysAt(t reflect.Type, tagName string, parents []string) []string {
+	for _, key := range parents {
+		ft, ok := structKeys(t, tagName)[key]
+		if ok && isStructSlice(derefType(ft)) {
+			ft = derefType(ft).Elem()
+		}
+		if !ok || !isNestedStruct(derefType(ft)) {
+			return nil
+		}
+		t = ft
+	}
+	return sortedKeys(structKeys(t, tagName))
+}
+
+// Walk decoded map with struct fields and collect unknown keys.
+// Elements of slice of structs are walked with the same keys as toml reports like "list.key".
+// Fields which are not nested struct like map accept any keys.
+func walkUnknownKeys(t reflect.Type, tagName string, m interface{}, keys []string, unknowns *[][]string, suggestions *[]string) {
+	t = derefType(t)
+	if t.Kind() == reflect.Slice {
+		if elems, ok := m.([]interface{}); ok {
+			for _, elem := range elems {
+				walkUnknownKeys(t.Elem(), tagName, elem, keys, unknowns, suggestions)
+			}
+		}
+		return
+	}
+	if t.Kind() != reflect.Struct || !isNestedStruct(t) {
+		return
+	}
+	known := structKeys(t, tagName)
+	visit := func(key string, value interface{}) {
+		fieldKeys := append(append([]string{}, keys...), key)
+		ft, ok := known[key]
+		if !ok && tagName == tagNameJson {
+			// encoding/json matches keys case-insensitively
+			for name, nt := range known {
+				if strings.EqualFold(name, key) {
+					ft, ok = nt, true
+					break
+				}
+			}
+		}
+		if !ok {
+			// Same unknown key in other elements of the list is reported once
+			for _, unknown := range *unknowns {
+				if strings.Join(unknown, ".") == strings.Join(fieldKeys, ".") {
+					return
+				}
+			}
+			*unknowns = append(*unknowns, fieldKeys)
+			*suggestions = append(*suggestions, suggestKey(fieldKeys, sortedKeys(known)))
+			return
+		}
+		walkUnknownKeys(ft, tagName, value, fieldKeys, unknowns, suggestions)
+	}
+	switch mm := m.(type) {
+	case map[string]interface{}:
+		for key, value := range mm {
+			visit(key, value)
+		}
+	case map[interface{}]interface{}:
+		for key, value := range mm {
+			visit(fmt.Sprint(key), value)
+		}
+	}
+}
+
+// Check unknown keys in toml document via undecoded keys of metadata
+func strictToml(file string, buf []byte, t reflect.Type, md toml.MetaData) error {
+	var unknowns [][]string
+	var suggestions []string
+	reported := make(map[string]struct{})
+	for _, key := range md.Undecoded() {
+		// Report the topmost key only, e.g. unknown table and its keys
+		var skip bool
+		for i := 1; i < len(key); i++ {
+			if _, ok := reported[strings.Join(key[:i], ".")]; ok {
+				skip = true
+				break
+			}
+		}
+		if skip {
+			continue
+		}
+		reported[key.String()] = struct{}{}
+		keys := []string(key)
+		unknowns = append(unknowns, keys)
+		suggestions = append(suggestions, suggestKey(keys, knownKeysAt(t, tagNameToml, keys[:len(keys)-1])))
+	}
+	return unknownKeyErrors(optionNameToml, file, tomlLines(buf), unknowns, suggestions)
+}
+
+// Check unknown keys in yaml or json document which is decoded as map
+func strictMap(source, tagName, file string, buf []byte, t reflect.Type, m interface{}) error {
+	var unknowns [][]string
+	var suggestions []string
+	walkUnknownKeys(t, tagName, m, nil, &unknowns, &suggestions)
+	if len(unknowns) == 0 {
+		return nil
+	}
+	var lines map[string]int
+	if tagName == tagNameJson {
+		lines = jsonLines(buf)
+	} else {
+		lines = yamlLines(buf)
+	}
+	return unknownKeyErrors(source, file, lines, unknowns, suggestions)
+}
+
+// Check unknown sections and keys in ini file.
+// Keys in the default section are mapped to top level fields, and sections are mapped to nested struct fields.
+func strictIni(file string, buf []byte, t reflect.Type, cfg *ini.File) error {
+	var unknowns [][]string
+	var suggestions []string
+	known := structKeys(t, tagNameIni)
+
+	checkKeys := func(s *ini.Section, fields map[string]reflect.Type, parents []string) {
+		var leaves []string
+		for name, ft := range fields {
+			if !isNestedStruct(derefType(ft)) {
+				leaves = append(leaves, name)
+			}
+		}
+		sort.Strings(leaves)
+		for _, name := range s.KeyStrings() {
+			ft, ok := fields[name]
+			if ok && !isNestedStruct(derefType(ft)) {
+				continue
+			}
+			keys := append(append([]string{}, parents...), name)
+			unknowns = append(unknowns, keys)
+			suggestions = append(suggestions, suggestKey(keys, leaves))
+		}
+	}
+
+	// Nested section is named by dotted path like [server.tls], so resolve it from the root struct
+	nestedNames := func(fields map[string]reflect.Type) []string {
+		var names []string
+		for name, ft := range fields {
+			if isNestedStruct(derefType(ft)) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		return names
+	}
+	for _, s := range cfg.Sections() {
+		if s.Name() == ini.DefaultSection {
+			checkKeys(s, known, nil)
+			continue
+		}
+		fields := known
+		parts := strings.Split(s.Name(), ".")
+		for i, part := range parts {
+			ft, ok := fields[part]
+			if !ok || !isNestedStruct(derefType(ft)) {
+				keys := parts[:i+1]
+				unknowns = append(unknowns, []string{s.Name()})
+				if found := suggest(part, nestedNames(fields)); found != "" {
+					suggestions = append(suggestions, strings.Join(append(append([]string{}, keys[:i]...), found), "."))
+				} else {
+					suggestions = append(suggestions, "")
+				}
+				fields = nil
+				break
+			}
+			fields = structKeys(ft, tagNameIni)
+		}
+		if fields != nil {
+			checkKeys(s, fields, parts)
+		}
+	}
+	return unknownKeyErrors(optionNameIni, file, iniLines(buf), unknowns, suggestions)
+}
+
+// Split dotted key and trim quotes
+func splitKey(key string) []string {
+	parts := strings.Split(key, ".")
+	for i := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(parts[i]), `"'`)
+	}
+	return parts
+}
+
+// Find line numbers of keys in toml document by scanning tables and key/value pairs
+func tomlLines(buf []byte) map[string]int {
+	lines := make(map[string]int)
+	record := func(keys []string, line int) {
+		key := strings.Join(keys, ".")
+		if _, ok := lines[key]; !ok {
+			lines[key] = line
+		}
+	}
+
+	var table []string
+	for i, line := range strings.Split(string(buf), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || line[0] == '#':
+			continue
+		case line[0] == '[':
+			name := strings.TrimLeft(line, "[")
+			if idx := strings.Index(name, "]"); idx != -1 {
+				name = name[:idx]
+			}
+			table = splitKey(name)
+			record(table, i+1)
+		default:
+			if idx := strings.Index(line, "="); idx > 0 {
+				record(append(append([]string{}, table...), splitKey(line[:idx])...), i+1)
+			}
+		}
+	}
+	return lines
+}
+
+// Find line numbers of keys in yaml document by scanning indentation of mapping keys
+func yamlLines(buf []byte) map[string]int {
+	lines := make(map[string]int)
+	type entry struct {
+		indent int
+		key    string
+	}
+	var stack []entry
+
+	for i, line := range strings.Split(string(buf), "\n") {
+		content := strings.TrimSpace(line)
+		if content == "" || content[0] == '#' || content == "---" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		// Key of the list item like "- key: value" is recorded without index as same as toml
+		for strings.HasPrefix(content, "- ") {
+			trimmed := strings.TrimLeft(content[2:], " ")
+			indent += len(content) - len(trimmed)
+			content = trimmed
+		}
+		idx := strings.Index(content, ":")
+		if idx <= 0 || (idx+1 < len(content) && content[idx+1] != ' ') {
+			continue
+		}
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, entry{indent: indent, key: strings.Trim(content[:idx], `"'`)})
+
+		keys := make([]string, len(stack))
+		for j := range stack {
+			keys[j] = stack[j].key
+		}
+		key := strings.Join(keys, ".")
+		if _, ok := lines[key]; !ok {
+			lines[key] = i + 1
+		}
+	}
+	return lines
+}
+
+// Find line numbers of object keys in json document by tokenizing
+func jsonLines(buf []byte) map[string]int {
+	lines := make(map[string]int)
+	type frame struct {
+		object    bool
+		expectKey bool
+		key       string
+	}
+	var stack []*frame
+
+	// Value is consumed, so the parent object expects the next key
+	consumed := func() {
+		if len(stack) > 0 && stack[len(stack)-1].object {
+			stack[len(stack)-1].expectKey = true
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	for {
+		offset := dec.InputOffset()
+		token, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch tok := token.(type) {
+		case json.Delim:
+			switch tok {
+			case '{', '[':
+				if len(stack) > 0 {
+					stack[len(stack)-1].expectKey = false
+				}
+				stack = append(stack, &frame{object: tok == '{', expectKey: tok == '{'})
+			default:
+				stack = stack[:len(stack)-1]
+				consumed()
+			}
+		case string:
+			if len(stack) == 0 || !stack[len(stack)-1].object || !stack[len(stack)-1].expectKey {
+				consumed()
+				continue
+			}
+			top := stack[len(stack)-1]
+			top.key = tok
+			top.expectKey = false
+
+			// Keys in arrays are recorded without index as same as toml
+			keys := make([]string, 0, len(stack))
+			for _, f := range stack {
+				if f.object {
+					keys = append(keys, f.key)
+				}
+			}
+			key := strings.Join(keys, ".")
+			if _, ok := lines[key]; !ok {
+				// Offset points the end of previous token, so skip separators
+				lines[key] = bytes.Count(buf[:offset], []byte("\n")) + 1 + leadingNewlines(buf[offset:])
+			}
+		default:
+			consumed()
+		}
+	}
+	return lines
+}
+
+// Count newlines before the next token
+func leadingNewlines(buf []byte) int {
+	var n int
+	for _, b := range buf {
+		switch b {
+		case '\n':
+			n++
+		case ' ', '\t', '\r', ',', ':':
+			continue
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// Find line numbers of sections and keys in ini file
+func iniLines(buf []byte) map[string]int {
+	lines := make(map[string]int)
+	var section []string
+	for i, line := range strings.Split(string(buf), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+			continue
+		case line[0] == '[':
+			name := strings.TrimPrefix(line, "[")
+			if idx := strings.Index(name, "]"); idx != -1 {
+				name = name[:idx]
+			}
+			section = []string{strings.TrimSpace(name)}
+			if _, ok := lines[section[0]]; !ok {
+				lines[section[0]] = i + 1
+			}
+		default:
+			idx := strings.IndexAny(line, "=:")
+			if idx <= 0 {
+				continue
+			}
+			key := strings.Join(append(append([]string{}, section...), strings.TrimSpace(line[:idx])), ".")
+			if _, ok := lines[key]; !ok {
+				lines[key] = i + 1
+			}
+		}
+	}
+	return lines
+}
@@ -0,0 +1,118 @@
+package twist_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	twist "github.com/ysugimoto/twist"
+)
+
+func TestStrict(t *testing.T) {
+	type strictConfig struct {
+		Token  string            `toml:"token" yaml:"token" json:"token" ini:"token"`
+		Labels map[string]string `toml:"labels" yaml:"labels" json:"labels"`
+		Server struct {
+			Host string `toml:"host" yaml:"host" json:"host" ini:"host"`
+			Port int    `toml:"port" yaml:"port" json:"port" ini:"port"`
+		} `toml:"server" yaml:"server" json:"server" ini:"server"`
+		Upstreams []struct {
+			Host string `toml:"host" yaml:"host" json:"host"`
+		} `toml:"upstreams" yaml:"upstreams" json:"upstreams"`
+	}
+
+	tests := []struct {
+		name   string
+		option twist.Option
+		expect []twist.UnknownKeyError
+	}{
+		{
+			name:   "toml",
+			option: twist.WithTomlBytes([]byte("token = \"a\"\n\n[labels]\nany = \"b\"\n\n[server]\nhsot = \"localhost\"\nprot = 8080\n\n[other]\nkey = 1\n")),
+			expect: []twist.UnknownKeyError{
+				{Source: "toml", Line: 7, Key: "server.hsot", Suggestion: "server.host"},
+				{Source: "toml", Line: 8, Key: "server.prot", Suggestion: "server.port"},
+				{Source: "toml", Line: 10, Key: "other"},
+			},
+		},
+		{
+			name:   "yaml",
+			option: twist.WithYamlBytes([]byte("token: a\nlabels:\n  any: b\nserver:\n  hsot: localhost\n  port: 8080\ntokne: b\n")),
+			expect: []twist.UnknownKeyError{
+				{Source: "yaml", Line: 5, Key: "server.hsot", Suggestion: "server.host"},
+				{Source: "yaml", Line: 7, Key: "tokne", Suggestion: "token"},
+			},
+		},
+		{
+			name:   "json",
+			option: twist.WithJsonBytes([]byte("{\n  \"token\": \"a\",\n  \"labels\": {\"any\": \"b\"},\n  \"server\": {\n    \"host\": \"localhost\",\n    \"prot\": 8080\n  }\n}\n")),
+			expect: []twist.UnknownKeyError{
+				{Source: "json", Line: 6, Key: "server.prot", Suggestion: "server.port"},
+			},
+		},
+		{
+			name:   "toml list",
+			option: twist.WithTomlBytes([]byte("[[upstreams]]\nhost = \"a\"\n\n[[upstreams]]\nhots = \"b\"\n")),
+			expect: []twist.UnknownKeyError{
+				{Source: "toml", Line: 5, Key: "upstreams.hots", Suggestion: "upstreams.host"},
+			},
+		},
+		{
+			name:   "yaml list",
+			option: twist.WithYamlBytes([]byte("upstreams:\n  - host: a\n  - hots: b\n  - hots: c\n")),
+			expect: []twist.UnknownKeyError{
+				{Source: "yaml", Line: 3, Key: "upstreams.hots", Suggestion: "upstreams.host"},
+			},
+		},
+		{
+			name:   "json list",
+			option: twist.WithJsonBytes([]byte("{\n  \"upstreams\": [\n    {\"host\": \"a\"},\n    {\"hots\": \"b\"}\n  ]\n}\n")),
+			expect: []twist.UnknownKeyError{
+				{Source: "json", Line: 4, Key: "upstreams.hots", Suggestion: "upstreams.host"},
+			},
+		},
+		{
+			name:   "ini",
+			option: twist.WithIniBytes([]byte("token = a\n\n[server]\nhost = localhost\nport = 8080\nprot = 8080\n\n[sevrer]\nhost = localhost\n")),
+			expect: []twist.UnknownKeyError{
+				{Source: "ini", Line: 6, Key: "server.prot", Suggestion: "server.port"},
+				{Source: "ini", Line: 8, Key: "sevrer", Suggestion: "server"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := strictConfig{}
+			err := twist.Mix(&config, twist.Strict(), tt.option)
+			assert.Error(t, err)
+
+			var errs twist.UnknownKeyErrors
+			assert.True(t, errors.As(err, &errs))
+			actual := make([]twist.UnknownKeyError, len(errs))
+			for i := range errs {
+				actual[i] = *errs[i]
+				actual[i].File = ""
+			}
+			assert.Equal(t, tt.expect, actual)
+
+			// Unknown keys are ignored without strict mode
+			assert.NoError(t, twist.Mix(&strictConfig{}, tt.option))
+		})
+	}
+}
+
+func TestStrictMessage(t *testing.T) {
+	config := fixtureConfig{}
+	err := twist.Mix(&config, twist.Strict(), twist.WithToml("./fixtures/example.toml"))
+	var errs twist.UnknownKeyErrors
+	assert.True(t, errors.As(err, &errs))
+	assert.EqualError(t, errs, `./fixtures/example.toml:2: unknown toml key "toml_value"`)
+
+	err = twist.Mix(&config, twist.Strict(), twist.WithYamlBytes([]byte("server:\n  prot: 80\n")))
+	var e *twist.UnknownKeyError
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, `(reader):2: unknown yaml key "server.prot", did you mean "server.port"?`, e.Error())
+
+	// json key is matched case-insensitively as same as decoding
+	assert.NoError(t, twist.Mix(&config, twist.Strict(), twist.WithJsonBytes([]byte(`{"Token": "a", "Server": {"HOST": "b"}}`))))
+}
@@ -0,0 +1,53 @@
+package twist
+
+import (
+	"strings"
+)
+
+// Find the most similar name from candidates by edit distance for "did you mean" suggestion.
+// Returns empty string if there is no similar one.
+func suggest(name string, candidates []string) string {
+	var found string
+	best := -1
+	for _, c := range candidates {
+		if c == name {
+			continue
+		}
+		d := editDistance(strings.ToLower(name), strings.ToLower(c))
+		if d > 2 || d >= len([]rune(name)) {
+			continue
+		}
+		if best == -1 || d < best {
+			found = c
+			best = d
+		}
+	}
+	return found
+}
+
+// Calculate edit distance which counts adjacent transposition as one edit (optimal string alignment),
+// so that typos like "prot" for "port" are close
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
@@ -1,18 +1,20 @@
 package twist
 
 import (
+	"encoding"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
-
-	"encoding/json"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/go-ini/ini"
 	"github.com/go-yaml/yaml"
-	"github.com/pkg/errors"
 )
 
 // Tag name constants
@@ -24,18 +26,24 @@ const (
 	tagNameJson    = "json"
 	tagNameEnv     = "env"
 	tagNameCli     = "cli"
+	tagNameLayout  = "layout"
+	tagNameSep     = "sep"
+	tagNameKvSep   = "kvsep"
 )
 
-var isDebug = os.Getenv("TWIST_DEBUG") != ""
+// Default separators for slice and map values on string sources
+const (
+	defaultSep   = ","
+	defaultKvSep = "="
+)
 
-// Debug function
-// If CC_DEBUG environment is defined, output some logs
-func debug(args ...interface{}) {
-	if !isDebug {
-		return
-	}
-	fmt.Println(args...)
-}
+// Types which are treated specially on assignment
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	urlType             = reflect.TypeOf(url.URL{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
 
 // Dereference reflect.Value
 func derefValue(v reflect.Value) reflect.Value {
@@ -59,483 +67,791 @@ func derefType(v reflect.Type) reflect.Type {
 //  Mix(v, WithToml(), WithJson())            cascade order is toml -> json
 //  Mix(v, WithToml(), WithJson(), WithEnv()) cascade order is toml -> jsoa -> env
 func Mix(v interface{}, opts ...Option) error {
+	return mix(v, nil, opts...)
+}
+
+// Cascading function as same as Mix, but returns provenance report which describes
+// which source set each field value.
+func MixWithReport(v interface{}, opts ...Option) (Report, error) {
+	report := Report{}
+	if err := mix(v, report, opts...); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func mix(v interface{}, report Report, opts ...Option) error {
 	t := reflect.TypeOf(v)
-	if t.Kind() != reflect.Ptr {
-		return errors.New("Cascading value must be a struct")
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Cascading value must be a pointer to struct, got %v", t)
 	}
 	value := derefValue(reflect.ValueOf(v))
 	if !value.CanSet() {
 		return errors.New("destination value cannot set values")
 	}
 
+	ctx := newContext(report, opts)
 	for _, opt := range opts {
-		switch opt.name {
-		case optionNameToml:
-			if err := cascadeToml(opt.value.(string), value, reflect.New(t)); err != nil {
-				return errors.Wrap(err, "Failed to cascade toml")
-			}
-		case optionNameYaml:
-			if err := cascadeYaml(opt.value.(string), value, reflect.New(t)); err != nil {
-				return errors.Wrap(err, "Failed to cascade yaml")
-			}
-		case optionNameIni:
-			src, err := ini.Load(opt.value.(string))
-			if err != nil {
-				return errors.Wrap(err, "ini load error")
-			}
-			if err := cascadeIni(src, src.Section(""), value); err != nil {
-				return errors.Wrap(err, "Failed to cascade ini")
-			}
-		case optionNameJson:
-			if err := cascadeJson(opt.value.(string), value, reflect.New(t)); err != nil {
-				return errors.Wrap(err, "Failed to cascade json")
-			}
-		case optionNameEnv:
-			if err := cascadeEnv(value); err != nil {
-				return errors.Wrap(err, "Failed to cascade env")
-			}
-		case optionNameCli:
-			if err := cascadeCli(value, parseCliArgs(value, opt.value.([]string)), nil, false); err != nil {
-				return errors.Wrap(err, "Failed to cascade cli")
-			}
+		if opt.source == nil {
+			continue
+		}
+		ctx.source = opt.source.Name()
+		if err := opt.source.Cascade(ctx, value); err != nil {
+			return fmt.Errorf("Failed to cascade %s: %w", opt.source.Name(), err)
 		}
 	}
-	if err := cascadeDefault(value); err != nil {
-		return errors.Wrap(err, "failed to set default value")
+	ctx.source = sourceNameDefault
+	if err := cascadeDefault(ctx, value, planOf(value.Type())); err != nil {
+		return fmt.Errorf("failed to set default value: %w", err)
+	}
+	if errs := validate(value, planOf(value.Type()), nil); len(errs) > 0 {
+		return fmt.Errorf("Failed to validate: %w", errs)
 	}
 	return nil
 }
 
-// Parse toml file and merge to base struct
-func cascadeToml(file string, base, clone reflect.Value) error {
-	if _, err := toml.DecodeFile(file, clone.Interface()); err != nil {
-		return errors.Wrap(err, "toml decode error")
+// Create cascading context with settings applied.
+// Settings are applied before cascading regardless of options order.
+func newContext(report Report, opts []Option) *Context {
+	ctx := &Context{
+		report: report,
+		logger: defaultLogger(),
 	}
-	return mergeConfig(base, derefValue(clone), tagNameToml)
+	for _, opt := range opts {
+		if opt.apply != nil {
+			opt.apply(ctx)
+		}
+	}
+	return ctx
 }
 
-// Parse yaml file and merge to base struct
-func cascadeYaml(file string, base, clone reflect.Value) error {
-	if _, err := os.Stat(file); err != nil {
-		return errors.Wrap(err, "yaml file not exists")
+// Parse toml document and merge to base struct
+func cascadeToml(ctx *Context, file string, buf []byte, base, clone reflect.Value) error {
+	// Decode as map in order to know which keys are actually defined
+	var keys map[string]interface{}
+	if _, err := toml.Decode(string(buf), &keys); err != nil {
+		return &SourceError{Source: optionNameToml, Location: file, Err: err}
 	}
-	fp, err := os.Open(file)
+	md, err := toml.Decode(string(buf), clone.Interface())
 	if err != nil {
-		return errors.Wrap(err, "yaml file open error")
+		return &SourceError{Source: optionNameToml, Location: file, Err: maskDecodeError(err, tagNameToml, planOf(base.Type()), keys)}
+	}
+	if ctx.strict {
+		if err := strictToml(file, buf, clone.Type(), md); err != nil {
+			return err
+		}
+	}
+	return mergeConfig(ctx, file, base, derefValue(clone), tagNameToml, planOf(base.Type()), keys, "")
+}
+
+// Parse yaml document and merge to base struct
+func cascadeYaml(ctx *Context, file string, buf []byte, base, clone reflect.Value) error {
+	// Decode as map in order to know which keys are actually defined
+	var keys map[string]interface{}
+	if err := yaml.Unmarshal(buf, &keys); err != nil {
+		return &SourceError{Source: optionNameYaml, Location: file, Err: err}
 	}
-	defer fp.Close()
-	if err := yaml.NewDecoder(fp).Decode(clone.Interface()); err != nil {
-		return errors.Wrap(err, "yaml decode error")
+	if err := yaml.Unmarshal(buf, clone.Interface()); err != nil {
+		return &SourceError{Source: optionNameYaml, Location: file, Err: maskDecodeError(err, tagNameYaml, planOf(base.Type()), keys)}
 	}
-	return mergeConfig(base, derefValue(clone), tagNameYaml)
+	if ctx.strict {
+		if err := strictMap(optionNameYaml, tagNameYaml, file, buf, clone.Type(), keys); err != nil {
+			return err
+		}
+	}
+	return mergeConfig(ctx, file, base, derefValue(clone), tagNameYaml, planOf(base.Type()), keys, "")
 }
 
-// Parse JSON file and merge to base struct
-func cascadeJson(file string, base, clone reflect.Value) error {
-	if _, err := os.Stat(file); err != nil {
-		return errors.Wrap(err, "json file not exists")
+// Parse JSON document and merge to base struct
+func cascadeJson(ctx *Context, file string, buf []byte, base, clone reflect.Value) error {
+	// Decode as map in order to know which keys are actually defined
+	var keys map[string]interface{}
+	if err := json.Unmarshal(buf, &keys); err != nil {
+		return &SourceError{Source: optionNameJson, Location: file, Err: err}
 	}
-	fp, err := os.Open(file)
-	if err != nil {
-		return errors.Wrap(err, "json file open error")
+	if err := json.Unmarshal(buf, clone.Interface()); err != nil {
+		return &SourceError{Source: optionNameJson, Location: file, Err: maskDecodeError(err, tagNameJson, planOf(base.Type()), keys)}
 	}
-	defer fp.Close()
-	if err := json.NewDecoder(fp).Decode(clone.Interface()); err != nil {
-		return errors.Wrap(err, "json decode error")
+	if ctx.strict {
+		if err := strictMap(optionNameJson, tagNameJson, file, buf, clone.Type(), keys); err != nil {
+			return err
+		}
 	}
-	return mergeConfig(base, derefValue(clone), tagNameJson)
+	return mergeConfig(ctx, file, base, derefValue(clone), tagNameJson, planOf(base.Type()), keys, "")
 }
 
 // Find INI section value and merge to base struct
-// Note that currently we support only single section, so you can't define nested section.
-func cascadeIni(cfg *ini.File, s *ini.Section, v reflect.Value) error {
-	t := derefType(v.Type())
+// Nested struct is mapped to the section which name is dotted path of ini tags like [server.tls],
+// and keys which don't exist in the child section are inherited from the parent section like [server].
+// Default section is used for the root struct.
+func cascadeIni(ctx *Context, file string, cfg *ini.File, v reflect.Value, plan *typePlan, section string) error {
 	v = derefValue(v)
 
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		value := v.Field(i)
+	var s *ini.Section
+	if section == "" {
+		s = cfg.Section(ini.DefaultSection)
+	} else {
+		s, _ = cfg.GetSection(section)
+	}
 
-		if !value.CanSet() {
-			debug("cannot set: ", field.Name)
+	for _, f := range plan.fields {
+		if !f.exported {
+			ctx.logEvent(actionSkipped, f.path, "", "reason", "cannot set")
 			continue
 		}
-
-		ft := field.Type
-		var isPtr bool
-		if ft.Kind() == reflect.Ptr {
-			isPtr = true
-			ft = derefType(ft)
-		}
-
-		tag, ok := field.Tag.Lookup(tagNameIni)
-		if !ok || tag == "" || tag == "-" {
+		tag, ok := f.keys[tagNameIni]
+		if !ok || f.cyclic {
 			continue
 		}
-		if ft.Kind() == reflect.Struct {
-			if ss := cfg.Section(tag); ss != nil {
-				debug("subsection: ", tag, ss.KeyStrings())
-				if err := cascadeIni(cfg, ss, value); err != nil {
-					return errors.Wrap(err, "Failed to parse subsection")
-				}
+		value := v.Field(f.index)
+		if f.nested != nil {
+			name := strings.Join(f.keyPaths[tagNameIni], ".")
+			if !hasIniSection(cfg, name) {
+				ctx.logEvent(actionNotFound, f.path, name)
+				continue
+			}
+			// Nil pointer is allocated only if the section is defined
+			if f.isPtr && value.IsNil() {
+				ctx.logEvent(actionAllocated, f.path, name)
+				value.Set(reflect.New(f.typ))
+			}
+			if err := cascadeIni(ctx, file, cfg, value, f.nested, name); err != nil {
+				return err
 			}
 			continue
 		}
-		key := s.Key(tag)
-		if key == nil {
-			debug("key not found in ini: ", tag)
+		if s == nil {
+			ctx.logEvent(actionNotFound, f.path, tag)
 			continue
 		}
-		debug(field.Name, key.String(), ft.Kind())
-		if err := assignValue(ft, value, key.Value(), isPtr, false); err != nil {
-			return errors.Wrap(err, "failed to assign values")
+		key, err := s.GetKey(tag)
+		if err != nil {
+			ctx.logEvent(actionNotFound, f.path, tag)
+			continue
 		}
-		debug("assigned: ", tag, key.Value())
+		// Shadow keys which have the same name are collected into slice or map,
+		// otherwise the last one takes precedence
+		values := key.ValueWithShadows()
+		raw := values[len(values)-1]
+		if len(values) > 1 && isCollectionType(f.typ) {
+			raw = strings.Join(values, ",")
+			if err := assignCollection(f.field, value, values); err != nil {
+				return ctx.fieldError(file, f.path, f.field, raw, err)
+			}
+		} else if err := assignValue(f.field, value, raw, false); err != nil {
+			return ctx.fieldError(file, f.path, f.field, raw, err)
+		}
+		ctx.Record(f.path, file, maskValue(f.field, raw))
 	}
 	return nil
 }
 
+// Check the section or its child sections are defined in INI file
+func hasIniSection(cfg *ini.File, name string) bool {
+	for _, s := range cfg.Sections() {
+		if s.Name() == name || strings.HasPrefix(s.Name(), name+".") {
+			return true
+		}
+	}
+	return false
+}
+
 // Walk struct field and assign from environment variable
-func cascadeEnv(v reflect.Value) error {
-	t := derefType(v.Type())
+// prefix is accumulated name segments of nested structs like "DB_".
+// lookup finds the variable value, which is os.LookupEnv or the variables parsed from dotenv file.
+func cascadeEnv(ctx *Context, file string, lookup func(string) (string, bool), v reflect.Value, plan *typePlan, prefix string) error {
 	v = derefValue(v)
 
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		value := v.Field(i)
-
-		if !value.CanSet() {
-			debug("cannot set: ", field.Name)
+	for _, f := range plan.fields {
+		if !f.exported {
+			ctx.logEvent(actionSkipped, f.path, "", "reason", "cannot set")
 			continue
 		}
-
-		ft := field.Type
-		var isPtr bool
-		if ft.Kind() == reflect.Ptr {
-			isPtr = true
-			ft = derefType(ft)
+		if f.cyclic {
+			continue
 		}
-
-		if ft.Kind() == reflect.Struct {
-			if isPtr && value.IsNil() {
-				debug("Nested struct ", field.Name, " is nil, create pointer")
-				value.Set(reflect.New(ft))
+		value := v.Field(f.index)
+		if f.nested != nil {
+			if f.isPtr && value.IsNil() {
+				ctx.logEvent(actionAllocated, f.path, "")
+				value.Set(reflect.New(f.typ))
 			}
-			if err := cascadeEnv(value); err != nil {
-				return errors.Wrap(err, "Failed to cascade nested struct env")
+			if err := cascadeEnv(ctx, file, lookup, value, f.nested, ctx.envNestedPrefix(prefix, f)); err != nil {
+				return err
 			}
 			continue
 		}
-		tag, ok := field.Tag.Lookup(tagNameEnv)
-		if !ok || tag == "" || tag == "-" {
+		tag, ok := ctx.envName(prefix, f)
+		if !ok {
 			continue
 		}
-		envValue := os.Getenv(tag)
+		envValue, _ := lookup(tag)
 		if envValue == "" {
+			ctx.logEvent(actionNotFound, f.path, tag)
 			continue
 		}
-		debug(field.Name, envValue, ft.Kind())
-		if err := assignValue(ft, value, envValue, isPtr, false); err != nil {
-			return errors.Wrap(err, "failed to assign values")
+		location := tag
+		if file != "" {
+			location = file
+		}
+		if err := assignValue(f.field, value, envValue, false); err != nil {
+			return ctx.fieldError(location, f.path, f.field, envValue, err)
 		}
-		debug("assigned: ", field.Name, envValue)
+		ctx.Record(f.path, location, maskValue(f.field, envValue))
 	}
 	return nil
 }
 
 // Walk struct field and assign from default tagged value
-func cascadeDefault(v reflect.Value) error {
-	t := derefType(v.Type())
+func cascadeDefault(ctx *Context, v reflect.Value, plan *typePlan) error {
 	v = derefValue(v)
 
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		value := v.Field(i)
-
-		if !value.CanSet() {
-			debug("cannot set: ", field.Name)
+	for _, f := range plan.fields {
+		if !f.exported {
+			ctx.logEvent(actionSkipped, f.path, "", "reason", "cannot set")
 			continue
 		}
-
-		ft := field.Type
-		var isPtr bool
-		if ft.Kind() == reflect.Ptr {
-			isPtr = true
-			ft = derefType(ft)
+		if f.cyclic {
+			continue
 		}
-
-		if ft.Kind() == reflect.Struct {
-			if err := cascadeDefault(value); err != nil {
-				return errors.Wrap(err, "Failed to cascade default value for nested struct")
+		value := v.Field(f.index)
+		if f.nested != nil {
+			// Nil pointer is kept as unset because no source supplied the struct
+			if f.isPtr && value.IsNil() {
+				continue
+			}
+			if err := cascadeDefault(ctx, value, f.nested); err != nil {
+				return err
 			}
 			continue
 		}
-		if !isZeroValue(ft, value) {
+		if !f.hasDefault {
 			continue
 		}
-		tag, ok := field.Tag.Lookup(tagNameDefault)
-		if !ok || tag == "" || tag == "-" {
+		if !value.IsZero() {
+			ctx.logEvent(actionSkipped, f.path, "", "reason", "already assigned")
 			continue
 		}
-		if err := assignValue(ft, value, tag, isPtr, false); err != nil {
-			return errors.Wrap(err, "failed to assign values")
+		if err := assignValue(f.field, value, f.defaultValue, false); err != nil {
+			return ctx.fieldError("", f.path, f.field, f.defaultValue, err)
 		}
-		debug("assigned: ", field.Name, tag)
+		ctx.Record(f.path, "", maskValue(f.field, f.defaultValue))
 	}
 	return nil
 }
 
-func factoryBooleanFieldNames(v reflect.Value, fields map[string]struct{}) {
-	t := derefType(v.Type())
+// Walk struct field and assign from command-line arguments.
+// Consumed option names are deleted from cloned so that the caller can find unrecognized options.
+func cascadeCli(ctx *Context, v reflect.Value, cliOptions map[string][]string, cloned map[string][]string, plan *typePlan) error {
 	v = derefValue(v)
 
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		value := v.Field(i)
-
-		if !value.CanSet() {
+	for _, f := range plan.fields {
+		if !f.exported {
+			ctx.logEvent(actionSkipped, f.path, "", "reason", "cannot set")
 			continue
 		}
-
-		ft := field.Type
-		if ft.Kind() == reflect.Ptr {
-			ft = derefType(ft)
-		}
-
-		if ft.Kind() == reflect.Struct {
-			factoryBooleanFieldNames(value, fields)
+		if f.cyclic {
 			continue
 		}
-		if ft.Kind() != reflect.Bool {
+		value := v.Field(f.index)
+		if f.nested != nil {
+			if f.isPtr && value.IsNil() {
+				ctx.logEvent(actionAllocated, f.path, "")
+				value.Set(reflect.New(f.typ))
+			}
+			if err := cascadeCli(ctx, value, cliOptions, cloned, f.nested); err != nil {
+				return err
+			}
 			continue
 		}
-
-		tag, ok := field.Tag.Lookup(tagNameCli)
-		if !ok || tag == "" || tag == "-" {
+		if len(f.cliNames) == 0 {
 			continue
 		}
-		for _, name := range strings.Split(tag, ",") {
-			fields[strings.TrimSpace(name)] = struct{}{}
+		// Collect values from both short and long names
+		var cliValue []string
+		var cliName string
+		for _, name := range f.cliNames {
+			if vv, ok := cliOptions[name]; ok {
+				cliValue = append(cliValue, vv...)
+				if cliName == "" {
+					cliName = name
+				}
+				delete(cloned, name)
+			}
+		}
+		if cliName == "" {
+			ctx.logEvent(actionNotFound, f.path, f.field.Tag.Get(tagNameCli))
+			continue
 		}
-	}
-}
-
-// Walk struct field and assign from command-line arguments
-func cascadeCli(v reflect.Value, cliOptions map[string][]string, cloned map[string][]string, isNested bool) error {
-	t := derefType(v.Type())
-	v = derefValue(v)
 
-	if cloned == nil {
-		cloned = make(map[string][]string)
-		for key, val := range cliOptions {
-			cloned[key] = val
+		if isCollectionType(f.typ) {
+			// Repeated options are collected into slice or map
+			var values []string
+			for _, v := range cliValue {
+				if v != "" {
+					values = append(values, v)
+				}
+			}
+			if err := assignCollection(f.field, value, values); err != nil {
+				return ctx.fieldError(cliFlagName(cliName), f.path, f.field, strings.Join(values, ","), err)
+			}
+		} else {
+			for _, v := range cliValue {
+				if err := assignValue(f.field, value, v, true); err != nil {
+					return ctx.fieldError(cliFlagName(cliName), f.path, f.field, v, err)
+				}
+			}
 		}
+		ctx.Record(f.path, cliFlagName(cliName), maskValue(f.field, strings.Join(cliValue, ",")))
 	}
+	return nil
+}
 
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		value := v.Field(i)
-
-		if !value.CanSet() {
-			debug("cannot set: ", field.Name)
+// Merge override config
+// Only fields whose keys are defined in the decoded document are merged,
+// so that partial override files don't reset values set by earlier files.
+// defined is the document decoded as map at the level of the struct,
+// and prefix is the path of the slice element which is merged with the element type plan.
+func mergeConfig(ctx *Context, file string, v, merge reflect.Value, tagName string, plan *typePlan, defined interface{}, prefix string) error {
+	for _, f := range plan.fields {
+		path := joinPath(prefix, f.path)
+		if !f.exported {
+			ctx.logEvent(actionSkipped, path, "", "reason", "cannot set")
 			continue
 		}
-
-		ft := field.Type
-		var isPtr bool
-		if ft.Kind() == reflect.Ptr {
-			isPtr = true
-			ft = derefType(ft)
+		target := merge.Field(f.index)
+		if !target.IsValid() {
+			ctx.logEvent(actionSkipped, path, "", "reason", "invalid value")
+			continue
 		}
-
-		if ft.Kind() == reflect.Struct {
-			if isPtr && value.IsNil() {
-				debug("Nested struct ", field.Name, " is nil, create pointer")
-				value.Set(reflect.New(ft))
+		if f.inline[tagName] && f.nested != nil {
+			// Embedded struct is flattened so that its fields are defined at the same level.
+			// Nil pointer means the document doesn't have any field of the embedded struct.
+			if f.isPtr && target.IsNil() {
+				continue
 			}
-			if err := cascadeCli(value, cliOptions, cloned, true); err != nil {
-				return errors.Wrap(err, "Failed to cascade cli arguments for nested struct")
+			if err := mergeConfig(ctx, file, allocValue(ctx, v.Field(f.index), path, ""), derefValue(target), tagName, f.nested, defined, prefix); err != nil {
+				return err
 			}
 			continue
 		}
-		tag, ok := field.Tag.Lookup(tagNameCli)
-		if !ok || tag == "" || tag == "-" {
+		key, ok := f.keys[tagName]
+		if !ok {
 			continue
 		}
-		var cliValue []string
-		var found bool
-		for _, name := range strings.Split(tag, ",") {
-			if vv, ok := cliOptions[name]; ok {
-				cliValue = vv
-				found = true
-				delete(cloned, name)
-				break
-			}
-		}
-		if !found {
+		value, ok := lookupFieldKey(defined, key, tagName)
+		if !ok {
+			ctx.logEvent(actionNotFound, path, key)
 			continue
 		}
-
-		for _, v := range cliValue {
-			if err := assignValue(ft, value, v, isPtr, true); err != nil {
-				return errors.Wrap(err, "failed to assign values")
+		switch {
+		case f.nested != nil:
+			// Explicit null must not erase the struct which is set by earlier files
+			if f.isPtr && target.IsNil() {
+				ctx.logEvent(actionSkipped, path, key, "reason", "null value")
+				continue
 			}
+			if err := mergeConfig(ctx, file, allocValue(ctx, v.Field(f.index), path, key), derefValue(target), tagName, f.nested, value, prefix); err != nil {
+				return err
+			}
+		case isStructSlice(f.field.Type):
+			if err := mergeSlice(ctx, file, v.Field(f.index), target, tagName, value, f.field, path); err != nil {
+				return err
+			}
+		default:
+			v.Field(f.index).Set(target)
+			recordValue(ctx, file, path, f.field, target)
 		}
-		debug("assigned: ", field.Name, tag)
 	}
+	return nil
+}
 
-	// If nested cascading, skip following
-	if isNested {
+// Merge slice of structs element by element, so that each element keeps values of earlier files
+// which are not defined in the document. The length of the slice follows the document.
+func mergeSlice(ctx *Context, file string, v, merge reflect.Value, tagName string, defined interface{}, field reflect.StructField, path string) error {
+	if merge.IsNil() || isSecret(field) {
+		v.Set(merge)
+		recordValue(ctx, file, path, field, merge)
 		return nil
 	}
+	var elems []interface{}
+	if rv := reflect.ValueOf(defined); rv.Kind() == reflect.Slice {
+		for i := 0; i < rv.Len(); i++ {
+			elems = append(elems, rv.Index(i).Interface())
+		}
+	}
 
-	// Check unrecognized cli option remains, raise an error
-	if len(cloned) > 0 {
-		unrecognized := make([]string, len(cloned))
-		var idx int
-		for key := range cloned {
-			unrecognized[idx] = key
-			idx++
+	elemType := v.Type().Elem()
+	plan := planOf(elemType)
+	merged := reflect.MakeSlice(v.Type(), merge.Len(), merge.Len())
+	for i := 0; i < merge.Len(); i++ {
+		src, dst := merge.Index(i), merged.Index(i)
+		elemPath := fmt.Sprintf("%s[%d]", path, i)
+		if i >= v.Len() || i >= len(elems) || !isMap(elems[i]) {
+			dst.Set(src)
+			recordValue(ctx, file, elemPath, field, src)
+			continue
+		}
+		prev := v.Index(i)
+		if elemType.Kind() == reflect.Ptr {
+			if prev.IsNil() || src.IsNil() {
+				dst.Set(src)
+				recordValue(ctx, file, elemPath, field, src)
+				continue
+			}
+			// Copy the earlier element in order not to modify the struct which may be shared
+			dst.Set(reflect.New(elemType.Elem()))
+			dst.Elem().Set(prev.Elem())
+		} else {
+			dst.Set(prev)
 		}
-		var plural string
-		if len(unrecognized) > 1 {
-			plural = "s"
+		if err := mergeConfig(ctx, file, derefValue(dst), derefValue(src), tagName, plan, elems[i], elemPath); err != nil {
+			return err
 		}
-		return errors.WithStack(
-			fmt.Errorf("Unrecognized cli option%s: %s", plural, strings.Join(unrecognized, ", ")),
-		)
 	}
+	v.Set(merged)
 	return nil
 }
 
-// Merge override config
-func mergeConfig(v, merge reflect.Value, tagName string) error {
-	t := v.Type()
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		debug("processing: ", field.Name, field.Type.Kind())
-		target := merge.Field(i)
-		if !target.IsValid() {
-			debug("target invalid: ", field.Name)
-			continue
+// Record the value which is assigned as a whole.
+// Struct values in the value are recorded by each leaf field through the plan,
+// so that secret fields in slice or map of structs are masked.
+func recordValue(ctx *Context, file, path string, field reflect.StructField, v reflect.Value) {
+	t := derefType(v.Type())
+	if isSecret(field) {
+		ctx.Record(path, file, secretMask)
+		return
+	}
+	v = derefValue(v)
+	switch {
+	case !v.IsValid():
+		return
+	case isNestedStruct(t):
+		recordStruct(ctx, file, v, planOf(t), path)
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Map) && isNestedStruct(derefType(t.Elem())) && v.Len() > 0:
+		if t.Kind() == reflect.Slice {
+			for i := 0; i < v.Len(); i++ {
+				recordValue(ctx, file, fmt.Sprintf("%s[%d]", path, i), field, v.Index(i))
+			}
+			return
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, key := range keys {
+			recordValue(ctx, file, fmt.Sprintf("%s[%v]", path, key.Interface()), field, v.MapIndex(key))
 		}
-		tag, ok := field.Tag.Lookup(tagName)
-		if !ok || tag == "" || tag == "-" {
-			debug("tag not found: ", tagName, field.Name)
+	default:
+		ctx.Record(path, file, formatValue(v))
+	}
+}
+
+// Record leaf fields of the struct, prefix is the path of the struct which plan is compiled as the root
+func recordStruct(ctx *Context, file string, v reflect.Value, plan *typePlan, prefix string) {
+	for _, f := range plan.fields {
+		if !f.exported {
 			continue
 		}
-		if field.Type.Kind() == reflect.Struct {
-			debug("nested struct: ", field.Name)
-			if err := mergeConfig(v.Field(i), derefValue(target), tagName); err != nil {
-				return errors.Wrap(err, "Failed to merge config for nested struct field: "+field.Name)
+		value := v.Field(f.index)
+		if f.nested != nil {
+			if value = derefValue(value); value.IsValid() {
+				recordStruct(ctx, file, value, f.nested, prefix)
 			}
-		} else {
-			v.Field(i).Set(target)
+			continue
 		}
+		recordValue(ctx, file, joinPath(prefix, f.path), f.field, value)
 	}
-	return nil
 }
 
-// Check struct field has already been assigned some value from other cascading
-func isZeroValue(ft reflect.Type, value reflect.Value) bool {
-	switch ft.Kind() {
-	case reflect.String:
-		return value.String() == ""
-	case reflect.Bool:
-		return !value.Bool()
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return value.Int() == 0
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return value.Uint() == 0
-	case reflect.Float32, reflect.Float64:
-		return value.Float() == 0
+// Allocate nil pointer and return dereferenced value
+func allocValue(ctx *Context, v reflect.Value, path, key string) reflect.Value {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		ctx.logEvent(actionAllocated, path, key)
+		v.Set(reflect.New(v.Type().Elem()))
 	}
-	return false
+	return derefValue(v)
 }
 
-// Assign value which corresponds to struct fiele type.
-// Currently we only support some primitive values like (int, uint, float, string)
-// because configurations are enough to use those values.
-func assignValue(ft reflect.Type, value reflect.Value, envValue string, isPtr bool, cliAssign bool) error {
-	switch ft.Kind() {
-	case reflect.String:
-		if isPtr {
-			value.Set(reflect.ValueOf(&envValue))
-		} else {
-			value.SetString(envValue)
+// Get key name from struct tag value which may have some options like "name,omitempty"
+func tagKeyName(tag string) string {
+	if idx := strings.Index(tag, ","); idx != -1 {
+		return tag[:idx]
+	}
+	return tag
+}
+
+// Find the key in the map which is decoded from toml, yaml or JSON document.
+// Note that YAML decodes nested map as map[interface{}]interface{}.
+func lookupKey(m interface{}, key string) (interface{}, bool) {
+	switch mm := m.(type) {
+	case map[string]interface{}:
+		v, ok := mm[key]
+		return v, ok
+	case map[interface{}]interface{}:
+		v, ok := mm[key]
+		return v, ok
+	}
+	return nil, false
+}
+
+// Find the key of the field in the decoded map as same as the decoder of the tag resolves
+func lookupFieldKey(m interface{}, key, tagName string) (interface{}, bool) {
+	value, ok := lookupKey(m, key)
+	if !ok && tagName != tagNameYaml {
+		value, ok = lookupFoldKey(m, key)
+	}
+	return value, ok
+}
+
+// Find the key case-insensitively as same as toml and encoding/json decoders do when the exact key is not found
+func lookupFoldKey(m interface{}, key string) (interface{}, bool) {
+	mm, ok := m.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	for k, v := range mm {
+		if strings.EqualFold(k, key) {
+			return v, true
 		}
-	case reflect.Bool:
+	}
+	return nil, false
+}
+
+// Check the decoded value is map
+func isMap(m interface{}) bool {
+	switch m.(type) {
+	case map[string]interface{}, map[interface{}]interface{}:
+		return true
+	}
+	return false
+}
+
+// Check the type is slice of structs which are walked as nested struct
+func isStructSlice(ft reflect.Type) bool {
+	return ft.Kind() == reflect.Slice && isNestedStruct(derefType(ft.Elem()))
+}
+
+// Check struct type should be walked as nested struct.
+// Some struct types like time.Time, url.URL and encoding.TextUnmarshaler implementations
+// are treated as a single value.
+func isNestedStruct(ft reflect.Type) bool {
+	if ft.Kind() != reflect.Struct {
+		return false
+	}
+	switch {
+	case ft == timeType, ft == urlType:
+		return false
+	case reflect.PtrTo(ft).Implements(textUnmarshalerType):
+		return false
+	}
+	return true
+}
+
+// Assign value which corresponds to struct field type.
+// Primitive values (string, bool, int, uint, float), time.Duration, time.Time (parsed with "layout" tag),
+// url.URL and encoding.TextUnmarshaler implementations are supported.
+// Slice and map values are split by "sep" tag (default ",") and each element is converted as above.
+func assignValue(field reflect.StructField, value reflect.Value, envValue string, cliAssign bool) error {
+	ft := field.Type
+	var isPtr bool
+	if ft.Kind() == reflect.Ptr {
+		isPtr = true
+		ft = derefType(ft)
+	}
+
+	// Value-less cli option is only meaningful for boolean and string field
+	if cliAssign && envValue == "" && ft.Kind() != reflect.Bool && ft.Kind() != reflect.String {
+		return nil
+	}
+
+	var parsed reflect.Value
+	switch {
+	case ft.Kind() == reflect.Bool:
 		var b bool
 		if cliAssign {
-			b = envValue == "" || envValue == "yes"
+			b = envValue == "" || envValue == "true" || envValue == "yes"
 		} else {
 			b = envValue == "true" || envValue == "yes"
 		}
-		if isPtr {
-			value.Set(reflect.ValueOf(&b))
-		} else {
-			value.SetBool(b)
+		parsed = reflect.New(ft).Elem()
+		parsed.SetBool(b)
+	case isCollectionType(ft):
+		sep := defaultSep
+		if tag, ok := field.Tag.Lookup(tagNameSep); ok && tag != "" {
+			sep = tag
 		}
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		if cliAssign && envValue == "" {
-			return nil
+		var values []string
+		if envValue != "" {
+			values = strings.Split(envValue, sep)
+		}
+		return maskError(field, assignCollection(field, value, values), envValue)
+	default:
+		var err error
+		if parsed, err = parseValue(ft, envValue, field.Tag); err != nil {
+			return maskError(field, err, envValue)
 		}
+	}
 
-		i, err := strconv.ParseInt(envValue, 10, 64)
-		if err != nil {
-			return errors.Wrap(err, "failed to convert from string to int")
+	if isPtr {
+		ptr := reflect.New(ft)
+		ptr.Elem().Set(parsed)
+		value.Set(ptr)
+	} else {
+		value.Set(parsed)
+	}
+	return nil
+}
+
+// Check the type is slice or map which is assigned from multiple string values
+func isCollectionType(ft reflect.Type) bool {
+	switch ft.Kind() {
+	case reflect.Slice, reflect.Map:
+		return !reflect.PtrTo(ft).Implements(textUnmarshalerType)
+	}
+	return false
+}
+
+// Assign slice or map value from multiple string values.
+// Slice element is converted as same as single value, and map entry is split by "kvsep" tag (default "=")
+// then key and value are converted respectively.
+func assignCollection(field reflect.StructField, value reflect.Value, values []string) error {
+	ft := field.Type
+	var isPtr bool
+	if ft.Kind() == reflect.Ptr {
+		isPtr = true
+		ft = derefType(ft)
+	}
+
+	kvsep := defaultKvSep
+	if tag, ok := field.Tag.Lookup(tagNameKvSep); ok && tag != "" {
+		kvsep = tag
+	}
+
+	var parsed reflect.Value
+	switch ft.Kind() {
+	case reflect.Slice:
+		parsed = reflect.MakeSlice(ft, 0, len(values))
+		for _, v := range values {
+			elem, err := parseValue(ft.Elem(), strings.TrimSpace(v), field.Tag)
+			if err != nil {
+				return maskError(field, fmt.Errorf("failed to convert slice element: %w", err), strings.TrimSpace(v))
+			}
+			parsed = reflect.Append(parsed, elem)
+		}
+	case reflect.Map:
+		parsed = reflect.MakeMapWithSize(ft, len(values))
+		for _, v := range values {
+			kv := strings.SplitN(v, kvsep, 2)
+			if len(kv) != 2 {
+				return fmt.Errorf("invalid map entry, key and value must be separated by %s", kvsep)
+			}
+			key, err := parseValue(ft.Key(), strings.TrimSpace(kv[0]), field.Tag)
+			if err != nil {
+				return maskError(field, fmt.Errorf("failed to convert map key: %w", err), strings.TrimSpace(kv[0]))
+			}
+			val, err := parseValue(ft.Elem(), strings.TrimSpace(kv[1]), field.Tag)
+			if err != nil {
+				return maskError(field, fmt.Errorf("failed to convert map value: %w", err), strings.TrimSpace(kv[1]))
+			}
+			parsed.SetMapIndex(key, val)
 		}
-		if isPtr {
-			value.Set(reflect.ValueOf(&i))
-		} else {
-			value.SetInt(i)
+	}
+
+	if isPtr {
+		ptr := reflect.New(ft)
+		ptr.Elem().Set(parsed)
+		value.Set(ptr)
+	} else {
+		value.Set(parsed)
+	}
+	return nil
+}
+
+// Parse string value as the type.
+// tag is used for getting some format hints like "layout" for time.Time.
+func parseValue(ft reflect.Type, s string, tag reflect.StructTag) (reflect.Value, error) {
+	v := reflect.New(ft).Elem()
+
+	switch ft {
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return v, fmt.Errorf("failed to convert from string to duration: %w", err)
 		}
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		if cliAssign && envValue == "" {
-			return nil
+		v.SetInt(int64(d))
+		return v, nil
+	case timeType:
+		layout := tag.Get(tagNameLayout)
+		if layout == "" {
+			layout = time.RFC3339
 		}
-		ui, err := strconv.ParseUint(envValue, 10, 64)
+		t, err := time.Parse(layout, s)
 		if err != nil {
-			return errors.Wrap(err, "failed to convert from string to uint")
+			return v, fmt.Errorf("failed to convert from string to time: %w", err)
 		}
-		if isPtr {
-			value.Set(reflect.ValueOf(&ui))
-		} else {
-			value.SetUint(ui)
+		v.Set(reflect.ValueOf(t))
+		return v, nil
+	case urlType:
+		u, err := url.Parse(s)
+		if err != nil {
+			return v, fmt.Errorf("failed to convert from string to url: %w", err)
 		}
-	case reflect.Float32, reflect.Float64:
-		if cliAssign && envValue == "" {
-			return nil
+		v.Set(reflect.ValueOf(*u))
+		return v, nil
+	}
+
+	if reflect.PtrTo(ft).Implements(textUnmarshalerType) {
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return v, fmt.Errorf("failed to unmarshal text to %s: %w", ft.String(), err)
 		}
-		f, err := strconv.ParseFloat(envValue, 64)
+		return v, nil
+	}
+
+	switch ft.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		v.SetBool(s == "true" || s == "yes")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, ft.Bits())
 		if err != nil {
-			return errors.Wrap(err, "failed to convert from string to float")
-		}
-		if isPtr {
-			value.Set(reflect.ValueOf(&f))
-		} else {
-			value.SetFloat(f)
+			return v, fmt.Errorf("failed to convert from string to int: %w", err)
 		}
-	case reflect.Slice:
-		if cliAssign && envValue == "" {
-			return nil
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		ui, err := strconv.ParseUint(s, 10, ft.Bits())
+		if err != nil {
+			return v, fmt.Errorf("failed to convert from string to uint: %w", err)
 		}
-		if isPtr {
-			value.Set(reflect.AppendSlice(value, reflect.ValueOf([]string{envValue})))
-		} else {
-			value.Set(reflect.AppendSlice(value, reflect.ValueOf([]string{envValue})))
+		v.SetUint(ui)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, ft.Bits())
+		if err != nil {
+			return v, fmt.Errorf("failed to convert from string to float: %w", err)
 		}
+		v.SetFloat(f)
+	default:
+		return v, fmt.Errorf("unsupported type: %s", ft.String())
 	}
-	return nil
+	return v, nil
+}
+
+// Format cli option name as flag string like "-h" or "--host"
+func cliFlagName(name string) string {
+	if len(name) == 1 {
+		return "-" + name
+	}
+	return "--" + name
 }
 
 // Parse command-line argument strings to map with short/long keys
-func parseCliArgs(value reflect.Value, args []string) map[string][]string {
+func parseCliArgs(value reflect.Value, args []string) (map[string][]string, []cliArg) {
 	options := make(map[string][]string)
+	var parsed []cliArg
 	size := len(args)
 
-	singleFields := make(map[string]struct{})
-	factoryBooleanFieldNames(value, singleFields)
+	singleFields := planOf(value.Type()).boolCliNames
 
 	isSingle := func(v string) bool {
 		_, ok := singleFields[v]
@@ -546,18 +862,19 @@ func parseCliArgs(value reflect.Value, args []string) map[string][]string {
 		v := args[i]
 		var name, value string
 
-		if v[0] != '-' || len(v) <= 1 {
+		if len(v) <= 1 || v[0] != '-' {
 			continue
 		}
+		position := i
+		flag, inline, hasInline := strings.Cut(v, "=")
 		if v[1] == '-' {
 			// Parse as long argument
-			kv := strings.Split(v, "=")
-			name = kv[0][2:]
+			name = flag[2:]
 			switch {
 			case isSingle(name):
 				value = ""
-			case len(kv) > 1:
-				value = kv[1]
+			case hasInline:
+				value = inline
 			case i+1 < size && !strings.HasPrefix(args[i+1], "-"):
 				value = args[i+1]
 				i++
@@ -578,11 +895,9 @@ func parseCliArgs(value reflect.Value, args []string) map[string][]string {
 			}
 		}
 
-		if _, ok := options[name]; !ok {
-			options[name] = []string{}
-		}
 		options[name] = append(options[name], value)
+		parsed = append(parsed, cliArg{name: name, flag: flag, position: position})
 	}
 
-	return options
+	return options, parsed
 }
@@ -1,13 +1,31 @@
 package twist_test
 
 import (
+	"bytes"
+	"errors"
+	"net"
+	"net/url"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	twist "github.com/ysugimoto/twist"
 )
 
+// Configuration struct which is shared by the tests reading fixtures/example.* files
+type fixtureConfig struct {
+	Verbose bool   `cli:"v,verbose"`
+	Token   string `toml:"token" yaml:"token" json:"token" ini:"token" env:"TWIST_TOKEN"`
+	Server  struct {
+		Host string `toml:"host" yaml:"host" json:"host" ini:"host" cli:"host"`
+		Port int    `toml:"port" yaml:"port" json:"port" ini:"port"`
+	} `toml:"server" yaml:"server" json:"server" ini:"server"`
+}
+
 func TestMixCli(t *testing.T) {
 	config := struct {
 		Token  string `cli:"t,token"`
@@ -107,6 +125,39 @@ func TestMixJson(t *testing.T) {
 	assert.Equal(t, 6666, config.Server.Port)
 }
 
+func TestMixJsonCaseInsensitiveKey(t *testing.T) {
+	config := struct {
+		Server struct {
+			Host string `json:"host"`
+			Port int    `json:"port"`
+		} `json:"server"`
+	}{}
+	err := twist.Mix(
+		&config,
+		twist.WithJsonBytes([]byte(`{"Server": {"HOST": "json.localhost", "Port": 8080}}`)),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "json.localhost", config.Server.Host)
+	assert.Equal(t, 8080, config.Server.Port)
+}
+
+func TestMixTomlCaseInsensitiveKey(t *testing.T) {
+	config := struct {
+		Server struct {
+			Host string `toml:"host"`
+			Port int    `toml:"port"`
+		} `toml:"server"`
+	}{}
+	err := twist.Mix(
+		&config,
+		twist.WithTomlBytes([]byte("[Server]\nHOST = \"toml.localhost\"\nPort = 8080\n")),
+		twist.Strict(),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "toml.localhost", config.Server.Host)
+	assert.Equal(t, 8080, config.Server.Port)
+}
+
 func TestMixYaml(t *testing.T) {
 	config := struct {
 		YamlValue string `yaml:"yaml_value"`
@@ -149,6 +200,84 @@ func TestMixEnv(t *testing.T) {
 	assert.Equal(t, 3333, config.Server.Port)
 }
 
+func TestMixEnvWithPrefix(t *testing.T) {
+	t.Setenv("MYAPP_TOKEN", "token_from_prefixed_env")
+	t.Setenv("MYAPP_SERVER_HOST", "prefixed.localhost")
+	t.Setenv("MYAPP_SERVER_PORT", "4444")
+	t.Setenv("MYAPP_PRIMARY_HOST", "primary.localhost")
+	t.Setenv("MYAPP_REPLICA_HOST", "replica.localhost")
+	t.Setenv("MYAPP_API_KEY", "api_key")
+
+	type Database struct {
+		Host string
+	}
+	config := struct {
+		Token  string `env:"TOKEN"`
+		Server struct {
+			Host string
+			Port int
+		}
+		Primary Database `envPrefix:"PRIMARY_"`
+		Replica Database `envPrefix:"REPLICA_"`
+		APIKey  string
+		Ignored string `env:"-"`
+	}{}
+	err := twist.Mix(
+		&config,
+		twist.WithEnv(),
+		twist.WithEnvPrefix("MYAPP"),
+		twist.WithAutoEnv(),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "token_from_prefixed_env", config.Token)
+	assert.Equal(t, "prefixed.localhost", config.Server.Host)
+	assert.Equal(t, 4444, config.Server.Port)
+	assert.Equal(t, "primary.localhost", config.Primary.Host)
+	assert.Equal(t, "replica.localhost", config.Replica.Host)
+	assert.Equal(t, "api_key", config.APIKey)
+	assert.Equal(t, "", config.Ignored)
+}
+
+func TestMixDotenv(t *testing.T) {
+	config := struct {
+		Token  string `env:"TOKEN"`
+		Server struct {
+			Host string `env:"HOST"`
+			Port int    `env:"PORT"`
+		}
+		Greeting  string `env:"GREETING"`
+		Message   string `env:"MESSAGE"`
+		Multiline string `env:"MULTILINE"`
+		Fallback  string `env:"FALLBACK"`
+	}{}
+	err := twist.Mix(
+		&config,
+		twist.WithDotenv("./fixtures/example.env"),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "token_from_dotenv", config.Token)
+	assert.Equal(t, "dotenv.localhost", config.Server.Host)
+	assert.Equal(t, 5555, config.Server.Port)
+	assert.Equal(t, "hello ${HOST}", config.Greeting)
+	assert.Equal(t, "hello dotenv.localhost\nport is 5555 and costs $5", config.Message)
+	assert.Equal(t, "first line\nsecond line", config.Multiline)
+	assert.Equal(t, "fallback", config.Fallback)
+
+	// Process environment is not mutated
+	_, ok := os.LookupEnv("GREETING")
+	assert.False(t, ok)
+
+	// Escaped backslash is not confused with escaped "$"
+	escaped := struct {
+		Path   string `env:"DOTENV_PATH"`
+		Dollar string `env:"DOTENV_DOLLAR"`
+	}{}
+	err = twist.Mix(&escaped, twist.WithDotenvBytes([]byte("HOST=dotenv.localhost\nDOTENV_PATH=\"a\\\\$HOST\"\nDOTENV_DOLLAR=\"a\\$HOST\"\n")))
+	assert.NoError(t, err)
+	assert.Equal(t, `a\dotenv.localhost`, escaped.Path)
+	assert.Equal(t, "a$HOST", escaped.Dollar)
+}
+
 func TestMixTomlAndJson(t *testing.T) {
 	config := struct {
 		TomlValue string `toml:"toml_value"`
@@ -277,3 +406,271 @@ func TestMixCliWithUnrecognized(t *testing.T) {
 	err := twist.Mix(&config, twist.WithCli([]string{"-i"}))
 	assert.Error(t, err)
 }
+
+func TestMixCliWithSuggestion(t *testing.T) {
+	var config struct {
+		Verbose bool `cli:"v,verbose"`
+		Server  struct {
+			Host string `cli:"host"`
+			Port int    `cli:"p,port"`
+		}
+	}
+	err := twist.Mix(&config, twist.WithCli([]string{"-v", "--hots", "localhost", "-x", "--prot=80", "--hots", "example.com", "--unknown"}))
+	assert.Error(t, err)
+
+	var errs twist.UnknownOptionErrors
+	assert.True(t, errors.As(err, &errs))
+	assert.Equal(t, twist.UnknownOptionErrors{
+		{Option: "--hots", Position: 1, Suggestion: "--host"},
+		{Option: "-x", Position: 3},
+		{Option: "--prot", Position: 4, Suggestion: "--port"},
+		{Option: "--unknown", Position: 7},
+	}, errs)
+	assert.EqualError(t, errs, "unknown flag --hots, did you mean --host?, unknown flag -x, unknown flag --prot, did you mean --port?, unknown flag --unknown")
+}
+
+func TestMixMultipleYaml(t *testing.T) {
+	config := struct {
+		Token  string `yaml:"token"`
+		Server struct {
+			Host string `yaml:"host"`
+			Port int    `yaml:"port"`
+		} `yaml:"server"`
+	}{}
+	err := twist.Mix(
+		&config,
+		twist.WithYaml("./fixtures/example.yaml"),
+		twist.WithYaml("./fixtures/example.override.yaml"),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "token_from_yaml", config.Token)
+	assert.Equal(t, "yaml.localhost", config.Server.Host)
+	assert.Equal(t, 8080, config.Server.Port)
+}
+
+func TestMixMultipleJson(t *testing.T) {
+	config := struct {
+		Token  string `json:"token"`
+		Server struct {
+			Host string `json:"host"`
+			Port int    `json:"port"`
+		} `json:"server"`
+	}{}
+	err := twist.Mix(
+		&config,
+		twist.WithJson("./fixtures/example.json"),
+		twist.WithJson("./fixtures/example.override.json"),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "token_from_json", config.Token)
+	assert.Equal(t, "json.localhost", config.Server.Host)
+	assert.Equal(t, 6060, config.Server.Port)
+}
+
+type mapSource map[string]string
+
+func (m mapSource) Name() string {
+	return "map"
+}
+
+func (m mapSource) Cascade(ctx *twist.Context, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := t.Field(i).Tag.Get("map")
+		if val, ok := m[key]; ok {
+			v.Field(i).SetString(val)
+			ctx.Record(t.Field(i).Name, key, val)
+		}
+	}
+	return nil
+}
+
+func TestMixWithSource(t *testing.T) {
+	config := struct {
+		Token string `toml:"token" map:"token"`
+		Host  string `map:"host" default:"default.localhost"`
+	}{}
+	err := twist.Mix(
+		&config,
+		twist.WithToml("./fixtures/example.toml"),
+		twist.WithSource(mapSource{"token": "token_from_map"}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "token_from_map", config.Token)
+	assert.Equal(t, "default.localhost", config.Host)
+}
+
+func TestMixWithReport(t *testing.T) {
+	t.Setenv("PORT", "3333")
+
+	config := struct {
+		Token  string `toml:"token" map:"token"`
+		Server struct {
+			Host string `toml:"host" cli:"h,host"`
+			Port int    `toml:"port" env:"PORT"`
+		} `toml:"server"`
+		Protocol string `default:"tcp"`
+	}{}
+	report, err := twist.MixWithReport(
+		&config,
+		twist.WithToml("./fixtures/example.toml"),
+		twist.WithSource(mapSource{"token": "token_from_map"}),
+		twist.WithEnv(),
+		twist.WithCli([]string{"--host", "cli.localhost"}),
+	)
+	assert.NoError(t, err)
+
+	expects := map[string][]twist.Provenance{
+		"Token": {
+			{Source: "toml", Location: "./fixtures/example.toml", Value: "token_from_toml"},
+			{Source: "map", Location: "token", Value: "token_from_map"},
+		},
+		"Server.Host": {
+			{Source: "toml", Location: "./fixtures/example.toml", Value: "toml.localhost"},
+			{Source: "cli", Location: "--host", Value: "cli.localhost"},
+		},
+		"Server.Port": {
+			{Source: "toml", Location: "./fixtures/example.toml", Value: "9999"},
+			{Source: "env", Location: "PORT", Value: "3333"},
+		},
+		"Protocol": {
+			{Source: "default", Location: "", Value: "tcp"},
+		},
+	}
+	assert.Len(t, report, len(expects))
+	for path, history := range expects {
+		assert.Len(t, report[path], len(history))
+		for i := range history {
+			assert.Equal(t, history[i], *report[path][i])
+		}
+	}
+
+	p, ok := report.Lookup("Server.Port")
+	assert.True(t, ok)
+	assert.Equal(t, "env", p.Source)
+	assert.Contains(t, report.Explain(), "(overridden)")
+}
+
+func TestMixWithTextValues(t *testing.T) {
+	t.Setenv("TIMEOUT", "30s")
+	t.Setenv("ENDPOINT", "https://example.com/api")
+
+	var config struct {
+		Timeout  time.Duration `env:"TIMEOUT"`
+		Interval time.Duration `default:"1m30s"`
+		Since    time.Time     `default:"2023-01-02" layout:"2006-01-02"`
+		Until    *time.Time    `cli:"until"`
+		Endpoint url.URL       `env:"ENDPOINT"`
+		Addr     net.IP        `cli:"a,addr"`
+		Port     *int          `cli:"p,port"`
+	}
+	err := twist.Mix(
+		&config,
+		twist.WithEnv(),
+		twist.WithCli([]string{"--until", "2024-03-04T05:06:07Z", "-a", "192.168.0.1", "-p", "8080"}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, config.Timeout)
+	assert.Equal(t, 90*time.Second, config.Interval)
+	assert.Equal(t, time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), config.Since)
+	assert.Equal(t, time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC), *config.Until)
+	assert.Equal(t, "https://example.com/api", config.Endpoint.String())
+	assert.Equal(t, net.ParseIP("192.168.0.1"), config.Addr)
+	assert.Equal(t, 8080, *config.Port)
+}
+
+func TestMixWithInvalidDuration(t *testing.T) {
+	t.Setenv("TIMEOUT", "30")
+
+	var config struct {
+		Timeout time.Duration `env:"TIMEOUT"`
+	}
+	err := twist.Mix(&config, twist.WithEnv())
+	assert.Error(t, err)
+}
+
+func TestMixWithCollections(t *testing.T) {
+	t.Setenv("PORTS", "80, 443")
+	t.Setenv("WEIGHTS", "0.5;1.5")
+	t.Setenv("TIMEOUTS", "1s,1m")
+
+	var config struct {
+		Ports    []int             `env:"PORTS"`
+		Weights  []float64         `env:"WEIGHTS" sep:";"`
+		Timeouts []time.Duration   `env:"TIMEOUTS"`
+		Headers  map[string]string `default:"X-Foo:foo,X-Bar:bar" kvsep:":"`
+		Limits   map[string]int    `cli:"l,limit"`
+		Hosts    []string          `cli:"host" default:"localhost"`
+	}
+	err := twist.Mix(
+		&config,
+		twist.WithEnv(),
+		twist.WithCli([]string{"--limit", "a=1", "-l", "b=2"}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{80, 443}, config.Ports)
+	assert.Equal(t, []float64{0.5, 1.5}, config.Weights)
+	assert.Equal(t, []time.Duration{time.Second, time.Minute}, config.Timeouts)
+	assert.Equal(t, map[string]string{"X-Foo": "foo", "X-Bar": "bar"}, config.Headers)
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, config.Limits)
+	assert.Equal(t, []string{"localhost"}, config.Hosts)
+}
+
+func TestMixWithInvalidCollection(t *testing.T) {
+	var config struct {
+		Ports []int `cli:"p,port"`
+	}
+	err := twist.Mix(&config, twist.WithCli([]string{"-p", "80", "-p", "http"}))
+	assert.Error(t, err)
+}
+
+func TestMixWithReaders(t *testing.T) {
+	config := struct {
+		Token  string `toml:"token" yaml:"token" json:"token" ini:"token" env:"TOKEN"`
+		Server struct {
+			Host string `toml:"host" yaml:"host" json:"host" ini:"host"`
+			Port int    `toml:"port" yaml:"port" json:"port" ini:"port" env:"PORT"`
+		} `toml:"server" yaml:"server" json:"server" ini:"server"`
+	}{}
+	report, err := twist.MixWithReport(
+		&config,
+		twist.WithTomlReader(strings.NewReader("token = \"toml\"\n[server]\nhost = \"toml.localhost\"\n")),
+		twist.WithYamlBytes([]byte("server:\n  port: 8080\n")),
+		twist.WithIniReader(bytes.NewBufferString("[server]\nhost = ini.localhost\nport = 7070\n")),
+		twist.WithJsonBytes([]byte(`{"token": "json"}`)),
+		twist.WithDotenvBytes([]byte("PORT=9090\n")),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "json", config.Token)
+	assert.Equal(t, "ini.localhost", config.Server.Host)
+	assert.Equal(t, 9090, config.Server.Port)
+	p, ok := report.Lookup("Server.Port")
+	assert.True(t, ok)
+	assert.Equal(t, "dotenv", p.Source)
+	assert.Equal(t, "(reader)", p.Location)
+}
+
+func TestMixWithFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config/app.toml": {Data: []byte("[server]\nport = 8080\n")},
+		"config/app.ini":  {Data: []byte("[server]\nhost = fs.localhost\nport = 8080\n")},
+	}
+	config := struct {
+		Server struct {
+			Host string `toml:"host" ini:"host"`
+			Port int    `toml:"port" ini:"port"`
+		} `toml:"server" ini:"server"`
+	}{}
+	err := twist.Mix(
+		&config,
+		twist.WithFS(fsys),
+		twist.WithToml("./config/app.toml"),
+		twist.WithIni("/config/app.ini"),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "fs.localhost", config.Server.Host)
+	assert.Equal(t, 8080, config.Server.Port)
+
+	err = twist.Mix(&config, twist.WithFS(fsys), twist.WithToml("config/missing.toml"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,164 @@
+package twist
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const tagNameUsage = "usage"
+
+// ErrHelp is returned from Mix when -h or --help option is passed on WithCli
+// and it is not defined as any cli tag in the struct.
+// Caller should print Usage() and exit when receive this error.
+var ErrHelp = errors.New("help requested")
+
+// Cli option names which recognized as help request automatically
+var helpOptionNames = []string{"h", "help"}
+
+// Usage option line
+type usageOption struct {
+	names    []string
+	typeName string
+	usage    string
+}
+
+// Left column of usage line like "-h, --host string"
+func (o usageOption) left() string {
+	return strings.TrimRight(formatCliNames(o.names)+" "+o.typeName, " ")
+}
+
+// Usage options grouped by nested struct
+type usageGroup struct {
+	name    string
+	options []usageOption
+}
+
+// Usage returns formatted help message of command-line options
+// which is generated from cli, usage, default and env tags of the struct fields.
+// Options in nested struct are grouped by the struct field path.
+func Usage(v interface{}) string {
+	t := derefType(reflect.TypeOf(v))
+	if t.Kind() != reflect.Struct {
+		return ""
+	}
+
+	groups := factoryUsageGroups(planOf(t), "", nil)
+
+	// Append help option if user does not define it
+	defined := make(map[string]struct{})
+	for _, g := range groups {
+		for _, o := range g.options {
+			for _, name := range o.names {
+				defined[name] = struct{}{}
+			}
+		}
+	}
+	var helpNames []string
+	for _, name := range helpOptionNames {
+		if _, ok := defined[name]; !ok {
+			helpNames = append(helpNames, name)
+		}
+	}
+	if len(helpNames) > 0 {
+		groups[0].options = append(groups[0].options, usageOption{
+			names: helpNames,
+			usage: "Show this help message",
+		})
+	}
+
+	// Align usage column across all groups
+	var width int
+	for _, g := range groups {
+		for _, o := range g.options {
+			if n := len(o.left()); n > width {
+				width = n
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	for i, g := range groups {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		if g.name == "" {
+			buf.WriteString("Options:\n")
+		} else {
+			fmt.Fprintf(&buf, "%s Options:\n", g.name)
+		}
+		for _, o := range g.options {
+			line := fmt.Sprintf("  %-*s    %s", width, o.left(), o.usage)
+			buf.WriteString(strings.TrimRight(line, " ") + "\n")
+		}
+	}
+	return buf.String()
+}
+
+// Walk compiled struct fields and collect usage options by nested struct.
+// Self-referencing fields are not walked as same as cascading.
+func factoryUsageGroups(plan *typePlan, path string, groups []*usageGroup) []*usageGroup {
+	group := &usageGroup{name: path}
+	groups = append(groups, group)
+
+	for _, f := range plan.fields {
+		if !f.exported || f.cyclic {
+			continue
+		}
+		if f.nested != nil {
+			groups = factoryUsageGroups(f.nested, f.path, groups)
+			continue
+		}
+		if len(f.cliNames) == 0 {
+			continue
+		}
+
+		var typeName string
+		if f.typ.Kind() != reflect.Bool {
+			typeName = f.typ.String()
+		}
+		usage := f.field.Tag.Get(tagNameUsage)
+		var notes []string
+		if f.hasDefault {
+			notes = append(notes, "default: "+maskValue(f.field, f.defaultValue))
+		}
+		if f.hasEnv && f.env != "" && f.env != "-" {
+			notes = append(notes, "env: "+f.env)
+		}
+		if len(notes) > 0 {
+			usage = strings.TrimSpace(usage + " (" + strings.Join(notes, ", ") + ")")
+		}
+		group.options = append(group.options, usageOption{
+			names:    f.cliNames,
+			typeName: typeName,
+			usage:    usage,
+		})
+	}
+
+	// Remove empty group except top-level
+	if len(group.options) == 0 && path != "" {
+		for i := range groups {
+			if groups[i] == group {
+				groups = append(groups[:i], groups[i+1:]...)
+				break
+			}
+		}
+	}
+	return groups
+}
+
+// Format cli names like "-h, --host"
+// Long option only name is indented in order to align with short names.
+func formatCliNames(names []string) string {
+	flags := make([]string, len(names))
+	for i := range names {
+		flags[i] = cliFlagName(names[i])
+	}
+	formatted := strings.Join(flags, ", ")
+	if len(names[0]) > 1 {
+		formatted = "    " + formatted
+	}
+	return formatted
+}
@@ -0,0 +1,52 @@
+package twist_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	twist "github.com/ysugimoto/twist"
+)
+
+type usageConfig struct {
+	Token   string `cli:"t,token" env:"TOKEN" usage:"Access token"`
+	Verbose bool   `cli:"verbose" usage:"Verbose output"`
+	Server  struct {
+		Host string `cli:"H,host" default:"localhost" usage:"Server host"`
+		Port int    `cli:"p,port" default:"8080" env:"PORT"`
+	}
+	Other string `env:"OTHER"`
+}
+
+func TestUsage(t *testing.T) {
+	expect := `Options:
+  -t, --token string    Access token (env: TOKEN)
+      --verbose         Verbose output
+  -h, --help            Show this help message
+
+Server Options:
+  -H, --host string     Server host (default: localhost)
+  -p, --port int        (default: 8080, env: PORT)
+`
+	assert.Equal(t, expect, twist.Usage(&usageConfig{}))
+}
+
+func TestMixCliWithHelp(t *testing.T) {
+	for _, arg := range []string{"-h", "--help"} {
+		var config usageConfig
+		err := twist.Mix(&config, twist.WithCli([]string{"--verbose", arg}))
+		assert.True(t, errors.Is(err, twist.ErrHelp))
+
+		// Help is requested even if other option is malformed
+		err = twist.Mix(&config, twist.WithCli([]string{"--port", "abc", arg}))
+		assert.True(t, errors.Is(err, twist.ErrHelp))
+	}
+
+	// Help option is not recognized when the struct defines it
+	var config struct {
+		Host string `cli:"h,host"`
+	}
+	err := twist.Mix(&config, twist.WithCli([]string{"-h", "localhost"}))
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost", config.Host)
+}
@@ -0,0 +1,171 @@
+package twist
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validation tag name constants
+const (
+	tagNameRequired = "required"
+	tagNameMin      = "min"
+	tagNameMax      = "max"
+	tagNameOneOf    = "oneof"
+	tagNamePattern  = "pattern"
+	tagNameLen      = "len"
+)
+
+// ValidationError represents a violation of the validation tag on the field
+type ValidationError struct {
+	// Dotted field path like "Server.Port"
+	Path string
+
+	// Violated tag name like "required", "min"
+	Tag string
+
+	// Description of the violation
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidationErrors aggregates all violations found in the struct
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i := range e {
+		messages[i] = e[i].Error()
+	}
+	return strings.Join(messages, ", ")
+}
+
+// Unwrap returns each violation so that errors.As() can find *ValidationError
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i := range e {
+		errs[i] = e[i]
+	}
+	return errs
+}
+
+// Walk struct field and validate with validation tags after all cascading has been done.
+// All violations are collected rather than returning at the first one.
+func validate(v reflect.Value, plan *typePlan, errs ValidationErrors) ValidationErrors {
+	v = derefValue(v)
+
+	for _, f := range plan.fields {
+		if !f.exported || f.cyclic {
+			continue
+		}
+		field := f.field
+		value := v.Field(f.index)
+		fieldPath := f.path
+		violate := func(tag, format string, args ...interface{}) {
+			errs = append(errs, &ValidationError{
+				Path:    fieldPath,
+				Tag:     tag,
+				Message: fmt.Sprintf(format, args...),
+			})
+		}
+
+		if tag, ok := field.Tag.Lookup(tagNameRequired); ok && tag == "true" && value.IsZero() {
+			violate(tagNameRequired, "required but not set")
+			continue
+		}
+
+		// Nil pointer is treated as unset so no more validation is needed
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				continue
+			}
+			value = derefValue(value)
+		}
+		if f.nested != nil {
+			errs = validate(value, f.nested, errs)
+			continue
+		}
+
+		// Unset optional field is not validated, only required tag checks the presence
+		if v.Field(f.index).IsZero() {
+			continue
+		}
+
+		if tag, ok := field.Tag.Lookup(tagNameMin); ok {
+			if n, ok := numericValue(value); !ok {
+				violate(tagNameMin, "min is not supported for %s", value.Type())
+			} else if min, err := strconv.ParseFloat(tag, 64); err != nil {
+				violate(tagNameMin, "invalid min tag value %q", tag)
+			} else if n < min {
+				violate(tagNameMin, "must be greater than or equal to %s", tag)
+			}
+		}
+		if tag, ok := field.Tag.Lookup(tagNameMax); ok {
+			if n, ok := numericValue(value); !ok {
+				violate(tagNameMax, "max is not supported for %s", value.Type())
+			} else if max, err := strconv.ParseFloat(tag, 64); err != nil {
+				violate(tagNameMax, "invalid max tag value %q", tag)
+			} else if n > max {
+				violate(tagNameMax, "must be less than or equal to %s", tag)
+			}
+		}
+		if tag, ok := field.Tag.Lookup(tagNameOneOf); ok {
+			var found bool
+			s := fmt.Sprint(value.Interface())
+			for _, candidate := range strings.Fields(tag) {
+				if s == candidate {
+					found = true
+					break
+				}
+			}
+			if !found {
+				violate(tagNameOneOf, "must be one of [%s]", strings.Join(strings.Fields(tag), ", "))
+			}
+		}
+		if tag, ok := field.Tag.Lookup(tagNamePattern); ok {
+			if value.Kind() != reflect.String {
+				violate(tagNamePattern, "pattern is not supported for %s", value.Type())
+			} else if f.patternErr != nil {
+				violate(tagNamePattern, "invalid pattern tag value %q", tag)
+			} else if !f.pattern.MatchString(value.String()) {
+				violate(tagNamePattern, "must match pattern %s", tag)
+			}
+		}
+		if tag, ok := field.Tag.Lookup(tagNameLen); ok {
+			var size int
+			switch value.Kind() {
+			case reflect.String:
+				size = utf8.RuneCountInString(value.String())
+			case reflect.Slice, reflect.Array, reflect.Map:
+				size = value.Len()
+			default:
+				violate(tagNameLen, "len is not supported for %s", value.Type())
+				continue
+			}
+			if expect, err := strconv.Atoi(tag); err != nil {
+				violate(tagNameLen, "invalid len tag value %q", tag)
+			} else if size != expect {
+				violate(tagNameLen, "length must be %d", expect)
+			}
+		}
+	}
+	return errs
+}
+
+// Get numeric value as float64 for comparison
+func numericValue(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,70 @@
+package twist_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	twist "github.com/ysugimoto/twist"
+)
+
+func TestMixValidation(t *testing.T) {
+	var config struct {
+		Token  string `env:"VALIDATION_TOKEN" required:"true"`
+		Level  string `default:"trace" oneof:"debug info warn"`
+		Server struct {
+			Host string `default:"localhost" pattern:"^[a-z.]+$"`
+			Port int    `cli:"p,port" min:"1" max:"65535"`
+		}
+		Region  string   `default:"jp" len:"2"`
+		Origins []string `cli:"o,origin" len:"2"`
+	}
+	err := twist.Mix(&config, twist.WithEnv(), twist.WithCli([]string{"-p", "70000", "-o", "a"}))
+	assert.Error(t, err)
+
+	var errs twist.ValidationErrors
+	assert.True(t, errors.As(err, &errs))
+	assert.Len(t, errs, 4)
+	assert.Equal(t, "Token", errs[0].Path)
+	assert.Equal(t, "required", errs[0].Tag)
+	assert.Equal(t, "Level", errs[1].Path)
+	assert.Equal(t, "oneof", errs[1].Tag)
+	assert.Equal(t, "Server.Port", errs[2].Path)
+	assert.Equal(t, "max", errs[2].Tag)
+	assert.Equal(t, "Origins", errs[3].Path)
+	assert.Equal(t, "len", errs[3].Tag)
+
+	var ve *twist.ValidationError
+	assert.True(t, errors.As(err, &ve))
+	assert.Equal(t, "Token", ve.Path)
+}
+
+func TestMixValidationPassed(t *testing.T) {
+	var config struct {
+		Token  string `cli:"t,token" required:"true" pattern:"^tk_"`
+		Level  string `default:"info" oneof:"debug info warn"`
+		Server struct {
+			Port int `default:"8080" min:"1" max:"65535"`
+		}
+	}
+	err := twist.Mix(&config, twist.WithCli([]string{"-t", "tk_foo"}))
+	assert.NoError(t, err)
+}
+
+func TestMixValidationSkipsUnsetOptional(t *testing.T) {
+	var config struct {
+		Level  string `oneof:"debug info warn"`
+		Name   string `pattern:"^[a-z]+$"`
+		Port   int    `min:"1" max:"65535"`
+		Region string `len:"2"`
+		Token  string `pattern:"^tk_" required:"true"`
+	}
+	err := twist.Mix(&config)
+
+	// Only required field is reported when nothing is set
+	var errs twist.ValidationErrors
+	if assert.True(t, errors.As(err, &errs)) && assert.Len(t, errs, 1) {
+		assert.Equal(t, "Token", errs[0].Path)
+		assert.Equal(t, "required", errs[0].Tag)
+	}
+}
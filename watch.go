@@ -0,0 +1,231 @@
+package twist
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// ChangeFunc is called when configuration is reloaded and some fields are changed.
+// old and new are pointers of the configuration struct, and changed is a list of dotted field paths.
+type ChangeFunc func(old, new interface{}, changed []string)
+
+// Watcher reloads configuration when any cascading file is changed or SIGHUP is received.
+// Reloading runs the full cascade with the same options into the new struct and swaps it atomically,
+// so you should get the configuration via Config() rather than reading the struct passed to NewWatcher().
+type Watcher struct {
+	typ  reflect.Type
+	opts []Option
+
+	current atomic.Value
+	mu      sync.Mutex
+	funcs   []ChangeFunc
+	stats   map[string]fileStat
+
+	errs      chan error
+	stop      chan struct{}
+	done      chan struct{}
+	started   atomic.Bool
+	closeOnce sync.Once
+}
+
+// File stat for detecting changes
+type fileStat struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// NewWatcher cascades configuration to v as same as Mix() and returns Watcher which can reload it
+func NewWatcher(v interface{}, opts ...Option) (*Watcher, error) {
+	if err := Mix(v, opts...); err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		typ:  derefType(reflect.TypeOf(v)),
+		opts: opts,
+		errs: make(chan error, 8),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	w.current.Store(v)
+	w.stats = w.statFiles()
+	return w, nil
+}
+
+// Config returns the pointer of current configuration struct
+func (w *Watcher) Config() interface{} {
+	return w.current.Load()
+}
+
+// OnChange registers callback which is called when configuration is changed
+func (w *Watcher) OnChange(fn ChangeFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.funcs = append(w.funcs, fn)
+}
+
+// Errors returns channel which receives reload errors.
+// When reloading is failed, the previous configuration is kept.
+func (w *Watcher) Errors() <-chan error {
+	return w.errs
+}
+
+// Default polling interval which is used when non-positive interval is passed to Start
+const defaultWatchInterval = time.Second
+
+// Start watching files by polling with interval, and SIGHUP signal in background.
+// Non-positive interval falls back to one second.
+// Calling Start more than once has no effect.
+func (w *Watcher) Start(interval time.Duration) {
+	if !w.started.CompareAndSwap(false, true) {
+		return
+	}
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+
+	go func() {
+		defer close(w.done)
+		defer signal.Stop(sig)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-sig:
+				w.reload()
+			case <-ticker.C:
+				stats := w.statFiles()
+				if w.isChanged(stats) {
+					w.reload()
+				}
+			}
+		}
+	}()
+}
+
+// Close stops watching and waits for the background goroutine to finish
+func (w *Watcher) Close() {
+	w.closeOnce.Do(func() {
+		close(w.stop)
+		if w.started.Load() {
+			<-w.done
+		}
+	})
+}
+
+// Reload runs the full cascade immediately and swaps configuration if succeeded
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	w.stats = w.statFiles()
+	next := reflect.New(w.typ).Interface()
+	if err := Mix(next, w.opts...); err != nil {
+		w.mu.Unlock()
+		return fmt.Errorf("Failed to reload: %w", err)
+	}
+	prev := w.current.Swap(next)
+	funcs := append([]ChangeFunc{}, w.funcs...)
+	w.mu.Unlock()
+
+	// Callbacks are called outside the lock so that they can call Watcher methods
+	changed := diffFields(reflect.ValueOf(prev), reflect.ValueOf(next), "", nil)
+	if len(changed) == 0 {
+		return nil
+	}
+	for _, fn := range funcs {
+		fn(prev, next, changed)
+	}
+	return nil
+}
+
+// Reload and report an error to the channel without blocking
+func (w *Watcher) reload() {
+	if err := w.Reload(); err != nil {
+		select {
+		case w.errs <- err:
+		default:
+		}
+	}
+}
+
+// Collect file stats which are cascaded by options
+func (w *Watcher) statFiles() map[string]fileStat {
+	stats := make(map[string]fileStat)
+	ctx := newContext(nil, w.opts)
+	for _, opt := range w.opts {
+		fs, ok := opt.source.(fileSource)
+		if !ok {
+			continue
+		}
+		for _, file := range fs.files(ctx) {
+			info, err := ctx.statFile(file)
+			if err != nil {
+				stats[file] = fileStat{}
+				continue
+			}
+			stats[file] = fileStat{
+				exists:  true,
+				size:    info.Size(),
+				modTime: info.ModTime(),
+			}
+		}
+	}
+	return stats
+}
+
+// Check any file is changed from the last reloading
+func (w *Watcher) isChanged(stats map[string]fileStat) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(stats) != len(w.stats) {
+		return true
+	}
+	for file, stat := range stats {
+		prev, ok := w.stats[file]
+		if !ok || prev != stat {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare struct fields and collect dotted paths of changed fields
+func diffFields(a, b reflect.Value, path string, changed []string) []string {
+	a = derefValue(a)
+	b = derefValue(b)
+	if !a.IsValid() || !b.IsValid() {
+		if a.IsValid() != b.IsValid() {
+			changed = append(changed, path)
+		}
+		return changed
+	}
+
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !isEmbeddedStruct(field) {
+			continue
+		}
+		fieldPath := joinPath(path, field.Name)
+		if isNestedStruct(derefType(field.Type)) {
+			changed = diffFields(a.Field(i), b.Field(i), fieldPath, changed)
+			continue
+		}
+		if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			changed = append(changed, fieldPath)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,83 @@
+package twist_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	twist "github.com/ysugimoto/twist"
+)
+
+func TestWatcherReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.toml")
+	assert.NoError(t, os.WriteFile(file, []byte("token = \"foo\"\n[server]\nport = 8080\n"), 0o644))
+
+	var config fixtureConfig
+	w, err := twist.NewWatcher(&config, twist.WithToml(file))
+	assert.NoError(t, err)
+	defer w.Close()
+	assert.Equal(t, 8080, w.Config().(*fixtureConfig).Server.Port)
+
+	var changed []string
+	w.OnChange(func(old, new interface{}, paths []string) {
+		assert.Equal(t, 8080, old.(*fixtureConfig).Server.Port)
+		assert.Equal(t, 9090, new.(*fixtureConfig).Server.Port)
+		changed = paths
+	})
+	assert.NoError(t, os.WriteFile(file, []byte("token = \"foo\"\n[server]\nhost = \"localhost\"\nport = 9090\n"), 0o644))
+	assert.NoError(t, w.Reload())
+	assert.Equal(t, []string{"Server.Host", "Server.Port"}, changed)
+	assert.Equal(t, "localhost", w.Config().(*fixtureConfig).Server.Host)
+
+	// Previous configuration is kept when reloading is failed
+	assert.NoError(t, os.WriteFile(file, []byte("token = "), 0o644))
+	assert.Error(t, w.Reload())
+	assert.Equal(t, 9090, w.Config().(*fixtureConfig).Server.Port)
+}
+
+func TestWatcherPolling(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.toml")
+	assert.NoError(t, os.WriteFile(file, []byte("token = \"foo\"\n"), 0o644))
+
+	var config fixtureConfig
+	w, err := twist.NewWatcher(&config, twist.WithToml(file))
+	assert.NoError(t, err)
+	defer w.Close()
+
+	changed := make(chan []string, 1)
+	w.OnChange(func(old, new interface{}, paths []string) {
+		changed <- paths
+	})
+	w.Start(10 * time.Millisecond)
+
+	assert.NoError(t, os.WriteFile(file, []byte("token = \"foobar\"\n"), 0o644))
+	select {
+	case paths := <-changed:
+		assert.Equal(t, []string{"Token"}, paths)
+		assert.Equal(t, "foobar", w.Config().(*fixtureConfig).Token)
+	case <-time.After(time.Second):
+		t.Fatal("configuration is not reloaded")
+	}
+
+	assert.NoError(t, os.WriteFile(file, []byte("token = "), 0o644))
+	select {
+	case err := <-w.Errors():
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("reload error is not reported")
+	}
+}
+
+func TestWatcherStartWithZeroInterval(t *testing.T) {
+	var config fixtureConfig
+	w, err := twist.NewWatcher(&config, twist.WithToml("./fixtures/example.toml"))
+	assert.NoError(t, err)
+
+	// Falls back to the default interval instead of panicking in background
+	assert.NotPanics(t, func() {
+		w.Start(0)
+	})
+	w.Close()
+}